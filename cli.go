@@ -0,0 +1,211 @@
+package svc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Commands dispatched by Main.
+const (
+	CommandRun         = "run"
+	CommandVersion     = "version"
+	CommandHealthcheck = "healthcheck"
+	CommandConfig      = "config"
+	CommandHelp        = "help"
+)
+
+const (
+	healthcheckTimeout = 5 * time.Second
+
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const cliUsage = `Usage: %[1]s [command] [flags]
+
+Commands:
+  run                       Run the service (default).
+  version                   Print the service's name, version and build info.
+  healthcheck [flags] [ready|live]
+                            Query the service's probe and exit non-zero if it fails (default ready).
+  config                    Print the effective configuration, with secrets redacted.
+  config docs [format]      Print the configuration reference as markdown (default) or json.
+  help                      Print this help.
+`
+
+// Main runs the command given by the process' command-line arguments, or the
+// ones of WithCommandArgs, see Execute, and exits with its status.
+func Main(s *SVC) {
+	args := os.Args[1:]
+	if s.commandArgs != nil {
+		args = s.commandArgs
+	}
+	os.Exit(s.Execute(args, os.Stdout, os.Stderr))
+}
+
+// FlagArgs returns the command-line arguments without the command dispatched
+// by Main, e.g. to be passed to ConfigFlags.
+func FlagArgs(args []string) []string {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[1:]
+	}
+	return args
+}
+
+// Execute runs the command given by the first argument and returns the exit
+// status. Without a command, or if the first argument is a flag, the service
+// gets run. The commands are:
+//   - run: runs the service, exiting with ExitCode.
+//   - version: prints the service's name and version, and the build info.
+//   - healthcheck [flags] [ready|live]: queries the ready (default) or live probe of
+//     WithHealthz on the port of the internal HTTP server, e.g. for a Docker
+//     HEALTHCHECK in images without curl.
+//   - config: prints the configuration loaded by WithConfig as JSON, redacted
+//     like WithConfigHandler does, and fails if it is invalid.
+//   - config docs [markdown|json]: prints the configuration reference, see
+//     WriteConfigReference.
+//   - help: prints the usage.
+//
+// With WithCommandArgs, WithConfig neither loads nor validates the
+// configuration for the version, help and config docs commands, and the config
+// command prints an invalid configuration along with its errors.
+func (s *SVC) Execute(args []string, stdout, stderr io.Writer) int {
+	command, args := parseCommand(args)
+	if command != CommandRun {
+		// The result is printed to stdout, e.g. to pipe `config` into jq.
+		s.logSink.set(zapcore.AddSync(stderr))
+	}
+
+	var err error
+	switch command {
+	case CommandRun:
+		s.Run()
+		return s.ExitCode()
+	case CommandVersion:
+		s.printVersion(stdout)
+	case CommandHealthcheck:
+		err = s.healthcheck(args)
+	case CommandConfig:
+		err = s.printConfig(stdout, args)
+	case CommandHelp:
+		fmt.Fprintf(stdout, cliUsage, s.Name)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n"+cliUsage, command, s.Name)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", command, err)
+		return exitError
+	}
+	return exitOK
+}
+
+// configLoading returns whether WithConfig loads the configuration for the
+// command given by the arguments, and whether it fails on an invalid one.
+func configLoading(args []string) (load, strict bool) {
+	command, args := parseCommand(args)
+	switch command {
+	case CommandVersion, CommandHelp:
+		return false, false
+	case CommandConfig:
+		// Only the config command itself prints the loaded configuration.
+		return len(args) == 0, false
+	}
+	return true, true
+}
+
+// parseCommand splits the command-line arguments into the command and its
+// arguments.
+func parseCommand(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return CommandRun, args
+}
+
+func (s *SVC) printVersion(w io.Writer) {
+	fmt.Fprintf(w, "%s %s\n", s.Name, s.Version)
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	fmt.Fprintf(w, "go: %s\n", info.GoVersion)
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified":
+			fmt.Fprintf(w, "%s: %s\n", strings.TrimPrefix(setting.Key, "vcs."), setting.Value)
+		}
+	}
+}
+
+func (s *SVC) healthcheck(args []string) error {
+	// The flags aren't parsed here; they only apply to the port if the options
+	// pass it to WithHTTPServer, e.g. from a config with ConfigFlags.
+	probe := "ready"
+	if len(args) > 0 && (args[len(args)-1] == "ready" || args[len(args)-1] == "live") {
+		probe = args[len(args)-1]
+	}
+	w, ok := s.workers[internalHTTPServerName].(*httpServer)
+	if !ok {
+		return fmt.Errorf("requires the WithHTTPServer option")
+	}
+	_, port, err := net.SplitHostPort(w.addr)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: healthcheckTimeout}
+	resp, err := client.Get("http://" + net.JoinHostPort("localhost", port) + "/" + probe)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s probe failed with status code %d: %s", probe, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (s *SVC) printConfig(w io.Writer, args []string) error {
+	if s.config == nil {
+		return fmt.Errorf("requires the WithConfig option")
+	}
+	config, loaded := s.config.current()
+
+	if len(args) > 0 {
+		if args[0] != "docs" {
+			return fmt.Errorf("unknown subcommand %q", args[0])
+		}
+		format := ConfigReferenceMarkdown
+		if len(args) > 1 {
+			format = args[1]
+		}
+		variables, err := configReference(config, loaded.prefix)
+		if err != nil {
+			return err
+		}
+		return writeConfigReference(w, variables, format)
+	}
+
+	b, err := json.MarshalIndent(loaded.entries(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, string(b)); err != nil {
+		return err
+	}
+	return s.config.loadErr
+}
@@ -0,0 +1,150 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestExecute(t *testing.T) {
+	type config struct {
+		Host     string `env:"HOST" envDefault:"localhost" desc:"Host to connect to."`
+		Password string `env:"PASSWORD" secret:"true"`
+	}
+	t.Setenv("PASSWORD", "s3cr3t")
+
+	probes := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/live" {
+			http.Error(w, "worker dummy: dead", http.StatusServiceUnavailable)
+		}
+	}))
+	defer probes.Close()
+	_, port, err := net.SplitHostPort(probes.Listener.Addr().String())
+	require.NoError(t, err)
+
+	s, err := New("dummy-service", "v1.2.3", WithConfig(&config{}), WithHTTPServer(port))
+	require.NoError(t, err)
+
+	execute := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := s.Execute(args, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, stdout, _ := execute("version")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "dummy-service v1.2.3\n")
+
+	code, stdout, _ = execute("config")
+	assert.Equal(t, 0, code)
+	var entries []configEntry
+	require.NoError(t, json.Unmarshal([]byte(stdout), &entries))
+	assert.Equal(t, []configEntry{
+		{Key: "HOST", Value: "localhost", Source: "default"},
+		{Key: "PASSWORD", Value: redactedConfigValue, Source: "env"},
+	}, entries)
+
+	code, stdout, _ = execute("config", "docs")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Host to connect to.")
+
+	code, _, _ = execute("healthcheck")
+	assert.Equal(t, 0, code)
+	code, _, _ = execute("healthcheck", "-host", "example.com", "ready")
+	assert.Equal(t, 0, code)
+	code, _, stderr := execute("healthcheck", "live")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "worker dummy: dead")
+
+	code, _, stderr = execute("deploy")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "deploy"`)
+
+	code, stdout, _ = execute("help")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Usage: dummy-service")
+}
+
+func TestExecuteConfigPrefix(t *testing.T) {
+	type config struct {
+		Port int `env:"PORT" envDefault:"8080"`
+	}
+	s, err := New("billing-api", "v1.2.3", WithConfig(&config{}, ConfigServicePrefix()))
+	require.NoError(t, err)
+
+	var stdout bytes.Buffer
+	require.Equal(t, 0, s.Execute([]string{"config", "docs", "json"}, &stdout, &bytes.Buffer{}))
+	var variables []ConfigVariable
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &variables))
+	require.Len(t, variables, 1)
+	assert.Equal(t, "BILLING_API_PORT", variables[0].Name)
+
+	stdout.Reset()
+	require.Equal(t, 0, s.Execute([]string{"config"}, &stdout, &bytes.Buffer{}))
+	assert.Contains(t, stdout.String(), `"BILLING_API_PORT"`)
+}
+
+func TestExecuteInvalidConfig(t *testing.T) {
+	type config struct {
+		Token string `env:"TOKEN,required" desc:"Token of the API."`
+		Port  int    `env:"PORT" envDefault:"8080"`
+	}
+	newService := func(args ...string) (*SVC, error) {
+		return New("dummy-service", "v1.2.3", WithCommandArgs(args), WithConfig(&config{}))
+	}
+	execute := func(s *SVC, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := s.Execute(args, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	_, err := newService("run")
+	require.Error(t, err)
+	_, err = newService("healthcheck")
+	require.Error(t, err)
+
+	s, err := newService("version")
+	require.NoError(t, err)
+	code, stdout, _ := execute(s, "version")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "dummy-service v1.2.3\n")
+
+	s, err = newService("config", "docs")
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.Lock(os.Stderr), s.logSink.w)
+	code, stdout, _ = execute(s, "config", "docs")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Token of the API.")
+
+	s, err = newService("config")
+	require.NoError(t, err)
+	code, stdout, stderr := execute(s, "config")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, `"PORT"`)
+	assert.Contains(t, stderr, "TOKEN is required")
+}
+
+func TestFlagArgs(t *testing.T) {
+	assert.Equal(t, []string{"-port", "80"}, FlagArgs([]string{"run", "-port", "80"}))
+	assert.Equal(t, []string{"-port", "80"}, FlagArgs([]string{"-port", "80"}))
+	assert.Empty(t, FlagArgs(nil))
+}
+
+func TestExecuteLogOutput(t *testing.T) {
+	s, err := New("dummy-service", "v1.2.3")
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, s.Execute([]string{"version"}, &stdout, &stderr))
+	s.Logger().Info("logged by the command")
+	assert.NotContains(t, stdout.String(), "logged by the command")
+	assert.Contains(t, stderr.String(), "logged by the command")
+}
@@ -0,0 +1,21 @@
+package svc
+
+import "time"
+
+// Clock provides the time to the service's lifecycle, e.g. to wait for the
+// termination wait and grace periods. It can be replaced with WithClock, e.g.
+// by a fake clock in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+var _ Clock = realClock{}
+
+// realClock is the Clock of the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
@@ -1,12 +1,138 @@
 package svc
 
 import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
 )
 
+const (
+	configSourceDefault = "default"
+	configSourceEnv     = "env"
+	configSourceFlag    = "flag"
+	configSourceFile    = "file"
+	configSourceSecret  = "secret-file"
+	configSourceDotEnv  = "dotenv"
+
+	// configSecretFileSuffix is the suffix of variables holding the path of a
+	// file to read a variable's value from.
+	configSecretFileSuffix = "_FILE"
+)
+
+// ConfigOption defines the option type of LoadConfig.
+type ConfigOption func(*configLoader)
+
+// ConfigFiles is a config option that reads the given YAML (.yaml, .yml) or
+// JSON (.json) files. The files contain a single object mapping environment
+// variable names to values, e.g. `PORT: 8080`. Lists are joined by the
+// field's separator. Later files take precedence over earlier ones.
+func ConfigFiles(paths ...string) ConfigOption {
+	return func(l *configLoader) {
+		l.files = append(l.files, paths...)
+	}
+}
+
+// ConfigFlags is a config option that parses the given command-line arguments,
+// usually os.Args[1:]. Every environment variable gets a flag named after it
+// in lower case with dashes, e.g. `DB_HOST` can be set by `-db-host`, and
+// `-db-host-file` for reading it from a file.
+func ConfigFlags(args []string) ConfigOption {
+	return func(l *configLoader) {
+		l.args = args
+		l.parseFlags = true
+	}
+}
+
+// ConfigPrefix is a config option that reads environment variables prefixed
+// by the given prefix, e.g. `BILLING_PORT` for `PORT` with the `BILLING_`
+// prefix. Unprefixed environment variables are ignored. Config file keys and
+// flags are not prefixed.
+func ConfigPrefix(prefix string) ConfigOption {
+	return func(l *configLoader) {
+		l.prefix = prefix
+		l.prefixFromName = false
+	}
+}
+
+// ConfigServicePrefix is a config option for WithConfig that reads environment
+// variables prefixed by the service's name in upper case, with other
+// characters than letters and digits replaced by underscores, e.g.
+// `BILLING_API_PORT` for `PORT` of the `billing-api` service. See ConfigPrefix.
+func ConfigServicePrefix() ConfigOption {
+	return func(l *configLoader) {
+		l.prefixFromName = true
+	}
+}
+
+// ConfigParsers is a config option that adds custom type parsers.
+func ConfigParsers(parsers map[reflect.Type]env.ParserFunc) ConfigOption {
+	return func(l *configLoader) {
+		for t, p := range parsers {
+			l.parsers[t] = p
+		}
+	}
+}
+
+// ConfigValidation is a config option that registers a custom validation
+// rule for use in `validate` tags.
+func ConfigValidation(tag string, fn validator.Func) ConfigOption {
+	return func(l *configLoader) {
+		l.validations = append(l.validations, func(v *validator.Validate) error {
+			return v.RegisterValidation(tag, fn)
+		})
+	}
+}
+
+// ConfigStructValidation is a config option that registers a struct-level
+// validation, e.g. for rules involving multiple fields, for the given types.
+func ConfigStructValidation(fn validator.StructLevelFunc, types ...interface{}) ConfigOption {
+	return func(l *configLoader) {
+		l.validations = append(l.validations, func(v *validator.Validate) error {
+			v.RegisterStructValidation(fn, types...)
+			return nil
+		})
+	}
+}
+
+// ConfigCustomType is a config option that registers a function returning the
+// value to validate for the given custom types.
+func ConfigCustomType(fn validator.CustomTypeFunc, types ...interface{}) ConfigOption {
+	return func(l *configLoader) {
+		l.validations = append(l.validations, func(v *validator.Validate) error {
+			v.RegisterCustomTypeFunc(fn, types...)
+			return nil
+		})
+	}
+}
+
+// ConfigSecretFiles is a config option that lets any variable be read from a
+// file instead, e.g. a mounted secret, by setting the variable suffixed with
+// `_FILE` to the file's path in any of the sources, or with the `-<flag>-file`
+// flag. The file's content is trimmed of surrounding whitespace and takes
+// precedence over the variable itself.
+func ConfigSecretFiles() ConfigOption {
+	return func(l *configLoader) {
+		l.secretFiles = true
+	}
+}
+
+// ConfigValidator defines a configuration that validates itself after its
+// fields got validated.
+type ConfigValidator interface {
+	Validate() error
+}
+
 // LoadFromEnv is a shortcut for LoadFromEnvWithParsers with empty custom parsers
 func LoadFromEnv(config interface{}) error {
 	return LoadFromEnvWithParsers(config, nil)
@@ -15,11 +141,479 @@ func LoadFromEnv(config interface{}) error {
 // LoadFromEnvWithParsers parses environment variables into a given struct and validates
 // its fields' values, also allows for custom type parsers
 func LoadFromEnvWithParsers(config interface{}, parsers map[reflect.Type]env.ParserFunc) error {
-	if err := env.ParseWithFuncs(config, parsers); err != nil {
-		return err
+	return LoadConfig(config, ConfigParsers(parsers))
+}
+
+// LoadConfig parses configuration into a given struct and validates its
+// fields' values. The configuration is merged from the following sources, in
+// increasing order of precedence:
+//
+//  1. defaults (`envDefault` tags)
+//  2. config files (ConfigFiles)
+//  3. .env files (ConfigDotEnv)
+//  4. environment variables
+//  5. command-line flags (ConfigFlags)
+//
+// After the `validate` tags, the configuration's Validate method is called if
+// it implements ConfigValidator.
+//
+// With ConfigSecretFiles, any variable can instead be read from a file.
+func LoadConfig(config interface{}, opts ...ConfigOption) error {
+	_, err := newConfigLoader(opts...).load(config)
+	return err
+}
+
+// configLoader loads configuration from layered sources.
+type configLoader struct {
+	prefix         string
+	prefixFromName bool
+	files          []string
+	dotEnvFiles    []string
+	dotEnvOverride bool
+	args           []string
+	parseFlags     bool
+	secretFiles    bool
+	parsers        map[reflect.Type]env.ParserFunc
+	validations    []func(*validator.Validate) error
+	environ        func() []string
+}
+
+// configValue is a configuration value and the source it came from.
+type configValue struct {
+	value  string
+	source string
+}
+
+// loadedConfig holds metadata about a loaded configuration.
+type loadedConfig struct {
+	prefix  string
+	fields  []configField
+	sources map[string]string
+	files   map[string]fileState
+}
+
+// variable returns the environment variable of a field.
+func (c *loadedConfig) variable(f configField) string {
+	return c.prefix + f.key
+}
+
+// fileNames returns the names of the files the configuration was read from.
+func (c *loadedConfig) fileNames() []string {
+	names := make([]string, 0, len(c.files))
+	for name := range c.files {
+		names = append(names, name)
+	}
+	return names
+}
+
+// fileState is the modification state of a file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFiles returns the modification state of the given files. Missing files
+// are recorded with a zero state.
+func statFiles(paths []string) map[string]fileState {
+	files := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err == nil {
+			files[path] = fileState{modTime: fi.ModTime(), size: fi.Size()}
+		} else {
+			files[path] = fileState{}
+		}
+	}
+	return files
+}
+
+func newConfigLoader(opts ...ConfigOption) *configLoader {
+	l := &configLoader{
+		parsers: DefaultParsers(),
+		environ: os.Environ,
 	}
-	if err := validator.New().Struct(config); err != nil {
-		return err
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+func (l *configLoader) load(config interface{}) (*loadedConfig, error) {
+	ref := reflect.ValueOf(config)
+	if ref.Kind() != reflect.Ptr || ref.Elem().Kind() != reflect.Struct {
+		return nil, env.ErrNotAStructPtr
+	}
+	if l.prefixFromName {
+		return nil, fmt.Errorf("config service prefix requires the WithConfig option")
+	}
+	fields := configFields(ref.Elem())
+
+	values, err := l.values(fields)
+	if err != nil {
+		return nil, err
+	}
+	var secretFiles []string
+	if l.secretFiles {
+		if secretFiles, err = readSecretFiles(values, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	sources := map[string]string{}
+	for _, f := range fields {
+		if v, ok := values[f.key]; ok {
+			sources[f.key] = v.source
+		} else if f.hasDefault {
+			sources[f.key] = configSourceDefault
+		}
+	}
+	loaded := &loadedConfig{
+		prefix:  l.prefix,
+		fields:  fields,
+		sources: sources,
+		files:   statFiles(append(append(append([]string{}, l.files...), l.dotEnvFiles...), secretFiles...)),
+	}
+
+	environment := make(map[string]string, len(values))
+	for k, v := range values {
+		environment[k] = v.value
+	}
+	configErr := &ConfigError{Fields: loaded.checkRequired()}
+	configErr.Fields = append(configErr.Fields, loaded.setFields(environment, l.parsers)...)
+
+	// The loaded config is returned along with its errors, so that the config
+	// command can print it.
+	if err := l.validate(config, loaded, configErr); err != nil {
+		return loaded, err
+	}
+	return loaded, nil
+}
+
+// validate validates the config, returning configErr with the validation
+// errors of the fields not already in it, if any.
+func (l *configLoader) validate(config interface{}, loaded *loadedConfig, configErr *ConfigError) error {
+	v := validator.New()
+	for _, register := range l.validations {
+		if err := register(v); err != nil {
+			return fmt.Errorf("config validation: %w", err)
+		}
+	}
+	if err := v.Struct(config); err != nil {
+		if err := loaded.addValidationErrors(configErr, err); err != nil {
+			return err
+		}
+	}
+	if len(configErr.Fields) > 0 {
+		return configErr
+	}
+	if cv, ok := config.(ConfigValidator); ok {
+		if err := cv.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
 	}
 	return nil
 }
+
+// readSecretFiles replaces the values of fields whose `_FILE` variable is set
+// with the content of that file. It returns the paths of the files read.
+func readSecretFiles(values map[string]configValue, fields []configField) ([]string, error) {
+	keys := map[string]bool{}
+	for _, f := range fields {
+		keys[f.key] = true
+	}
+
+	var files []string
+	for _, f := range fields {
+		// A field named like the `_FILE` variable is read as a regular value.
+		if keys[f.key+configSecretFileSuffix] {
+			continue
+		}
+		pathValue, ok := values[f.key+configSecretFileSuffix]
+		if !ok || pathValue.value == "" {
+			continue
+		}
+		path := pathValue.value
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config secret file for %s: %w", f.key, err)
+		}
+		values[f.key] = configValue{value: strings.TrimSpace(string(b)), source: configSourceSecret + ":" + path}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// values merges the configuration sources into a single environment.
+func (l *configLoader) values(fields []configField) (map[string]configValue, error) {
+	values := map[string]configValue{}
+
+	for _, path := range l.files {
+		fileValues, err := readConfigFile(path, fields, l.secretFiles)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileValues {
+			values[k] = configValue{value: v, source: configSourceFile + ":" + path}
+		}
+	}
+
+	environment := map[string]configValue{}
+	for _, kv := range l.environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			environment[k] = configValue{value: v, source: configSourceEnv}
+		}
+	}
+	dotEnv, err := readDotEnvFiles(l.dotEnvFiles)
+	if err != nil {
+		return nil, err
+	}
+	environmentLayers := []map[string]configValue{dotEnv, environment}
+	if l.dotEnvOverride {
+		environmentLayers = []map[string]configValue{environment, dotEnv}
+	}
+	for _, layer := range environmentLayers {
+		for k, v := range layer {
+			if strings.HasPrefix(k, l.prefix) {
+				values[strings.TrimPrefix(k, l.prefix)] = v
+			}
+		}
+	}
+
+	if l.parseFlags {
+		flagValues, err := parseConfigFlags(l.args, fields, l.secretFiles)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range flagValues {
+			values[k] = configValue{value: v, source: configSourceFlag}
+		}
+	}
+
+	return values, nil
+}
+
+// configField describes a configuration struct field read from an environment
+// variable.
+type configField struct {
+	key        string
+	namespace  string
+	field      reflect.StructField
+	value      reflect.Value
+	options    []string
+	defValue   string
+	hasDefault bool
+	separator  string
+}
+
+// configFields returns the fields of a configuration struct that are read from
+// environment variables. It follows the same traversal as caarlos0/env.
+func configFields(ref reflect.Value) []configField {
+	return appendConfigFields(nil, ref, ref.Type().Name())
+}
+
+func appendConfigFields(fields []configField, ref reflect.Value, namespace string) []configField {
+	refType := ref.Type()
+	for i := 0; i < refType.NumField(); i++ {
+		refField := ref.Field(i)
+		refTypeField := refType.Field(i)
+		if !refField.CanSet() {
+			continue
+		}
+		fieldNamespace := refTypeField.Name
+		if namespace != "" {
+			fieldNamespace = namespace + "." + fieldNamespace
+		}
+
+		key, options := parseConfigTag(refTypeField.Tag.Get("env"))
+		switch {
+		case refField.Kind() == reflect.Ptr && !refField.IsNil() && refField.Elem().Kind() == reflect.Struct:
+			fields = appendConfigFields(fields, refField.Elem(), fieldNamespace)
+			continue
+		case refField.Kind() == reflect.Struct && (refTypeField.Type.Name() == "" || key == ""):
+			fields = appendConfigFields(fields, refField, fieldNamespace)
+			continue
+		case key == "":
+			continue
+		}
+
+		separator := refTypeField.Tag.Get("envSeparator")
+		if separator == "" {
+			separator = ","
+		}
+		defValue, hasDefault := refTypeField.Tag.Lookup("envDefault")
+		fields = append(fields, configField{
+			key:        key,
+			namespace:  fieldNamespace,
+			field:      refTypeField,
+			value:      refField,
+			options:    options,
+			defValue:   defValue,
+			hasDefault: hasDefault,
+			separator:  separator,
+		})
+	}
+	return fields
+}
+
+func parseConfigTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+func (f configField) hasOption(option string) bool {
+	for _, o := range f.options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// flagName returns the command-line flag name of the field.
+func (f configField) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(f.key, "_", "-"))
+}
+
+// readConfigFile reads a YAML or JSON config file into environment variable
+// values.
+func readConfigFile(path string, fields []configField, secretFiles bool) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &raw)
+	case ".json":
+		err = json.Unmarshal(b, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	separators := map[string]string{}
+	for _, f := range fields {
+		separators[f.key] = f.separator
+	}
+	if secretFiles {
+		for _, f := range fields {
+			if _, ok := separators[f.key+configSecretFileSuffix]; !ok {
+				separators[f.key+configSecretFileSuffix] = ""
+			}
+		}
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		separator, ok := separators[k]
+		if !ok {
+			return nil, fmt.Errorf("config file %s: unknown key %q", path, k)
+		}
+		s, err := configFileValue(v, separator)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: key %q: %w", path, k, err)
+		}
+		values[k] = s
+	}
+	return values, nil
+}
+
+// configFileValue formats a decoded config file value the way it would be
+// written in an environment variable.
+func configFileValue(v interface{}, separator string) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			s, err := configFileValue(e, separator)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, separator), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("unsupported value of type %T", v)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// boolFlagValue is a flag.Value that can be set without an explicit value.
+type boolFlagValue struct{ stringFlagValue }
+
+func (boolFlagValue) IsBoolFlag() bool { return true }
+
+// stringFlagValue is a flag.Value recording the raw value for the config
+// loader to parse.
+type stringFlagValue struct {
+	key    string
+	values map[string]string
+}
+
+func (v stringFlagValue) String() string { return "" }
+
+func (v stringFlagValue) Set(s string) error {
+	v.values[v.key] = s
+	return nil
+}
+
+// parseConfigFlags parses command-line arguments into environment variable
+// values.
+func parseConfigFlags(args []string, fields []configField, secretFiles bool) (map[string]string, error) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	values := map[string]string{}
+	define := func(value flag.Value, name, usage string) {
+		if fs.Lookup(name) == nil {
+			fs.Var(value, name, usage)
+		}
+	}
+	for _, f := range fields {
+		value := stringFlagValue{key: f.key, values: values}
+		usage := f.field.Tag.Get("desc")
+		if usage == "" {
+			usage = fmt.Sprintf("sets %s", f.key)
+		}
+		if f.value.Kind() == reflect.Bool {
+			define(boolFlagValue{value}, f.flagName(), usage)
+		} else {
+			define(value, f.flagName(), usage)
+		}
+	}
+	if secretFiles {
+		for _, f := range fields {
+			define(stringFlagValue{key: f.key + configSecretFileSuffix, values: values},
+				f.flagName()+"-file", fmt.Sprintf("sets the file to read %s from", f.key))
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("config flags: %w", err)
+	}
+	return values, nil
+}
+
+// envPrefix returns the environment variable prefix for a service name.
+func envPrefix(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	b.WriteByte('_')
+	return b.String()
+}
@@ -1,12 +1,18 @@
 package svc
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/caarlos0/env/v6"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -28,7 +34,7 @@ func TestLoadFromEnv(t *testing.T) {
 
 	err = LoadFromEnv(&test)
 	require.Error(t, err)
-	require.Equal(t, "Key: 'EmptyRequiredVal' Error:Field validation for 'EmptyRequiredVal' failed on the 'required' tag", err.Error())
+	require.Equal(t, "invalid config: emptyVal is required", err.Error())
 	require.Equal(t, "testStrVal", test.StrVal)
 	require.Equal(t, 123, test.IntVal)
 }
@@ -49,3 +55,334 @@ func TestLoadFromEnvWithParsers(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, map[string]string{"testKey": "testVal"}, test.MapVal)
 }
+
+func TestLoadConfig(t *testing.T) {
+	type config struct {
+		Host     string   `env:"HOST" envDefault:"localhost"`
+		Port     int      `env:"PORT" envDefault:"8080" validate:"min=1"`
+		Tags     []string `env:"TAGS"`
+		Debug    bool     `env:"DEBUG"`
+		LogLevel string   `env:"LOG_LEVEL" envDefault:"info"`
+		Database struct {
+			Name string `env:"DB_NAME"`
+		}
+	}
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	require.NoError(t, os.WriteFile(base, []byte("PORT: 9090\nTAGS: [a, b]\nDB_NAME: base\nLOG_LEVEL: warn\n"), 0o600))
+	override := filepath.Join(dir, "override.json")
+	require.NoError(t, os.WriteFile(override, []byte(`{"DB_NAME": "override"}`), 0o600))
+
+	t.Setenv("PORT", "9191")
+	t.Setenv("LOG_LEVEL", "debug")
+
+	var cfg config
+	loaded, err := newConfigLoader(
+		ConfigFiles(base, override),
+		ConfigFlags([]string{"-log-level", "error", "-debug"}),
+	).load(&cfg)
+	require.NoError(t, err)
+
+	require.Equal(t, "localhost", cfg.Host)
+	require.Equal(t, 9191, cfg.Port)
+	require.Equal(t, []string{"a", "b"}, cfg.Tags)
+	require.True(t, cfg.Debug)
+	require.Equal(t, "error", cfg.LogLevel)
+	require.Equal(t, "override", cfg.Database.Name)
+
+	require.Equal(t, map[string]string{
+		"HOST":      "default",
+		"PORT":      "env",
+		"TAGS":      "file:" + base,
+		"DEBUG":     "flag",
+		"LOG_LEVEL": "flag",
+		"DB_NAME":   "file:" + override,
+	}, loaded.sources)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	type config struct {
+		Port int `env:"PORT" validate:"min=1"`
+	}
+
+	dir := t.TempDir()
+	unknownKey := filepath.Join(dir, "unknown.yaml")
+	require.NoError(t, os.WriteFile(unknownKey, []byte("PROT: 1\n"), 0o600))
+	unsupported := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(unsupported, []byte("PORT = 1\n"), 0o600))
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"PORT": 0}`), 0o600))
+
+	tests := []struct {
+		name string
+		opts []ConfigOption
+	}{
+		{name: "missing file", opts: []ConfigOption{ConfigFiles(filepath.Join(dir, "missing.yaml"))}},
+		{name: "unknown key", opts: []ConfigOption{ConfigFiles(unknownKey)}},
+		{name: "unsupported format", opts: []ConfigOption{ConfigFiles(unsupported)}},
+		{name: "invalid value", opts: []ConfigOption{ConfigFiles(invalid)}},
+		{name: "unknown flag", opts: []ConfigOption{ConfigFlags([]string{"-prot=1"})}},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg config
+			require.Error(t, LoadConfig(&cfg, tc.opts...))
+		})
+	}
+}
+
+func TestLoadConfigConcurrently(t *testing.T) {
+	type config struct {
+		Port int      `env:"PORT" envDefault:"8080"`
+		Size ByteSize `env:"SIZE" envDefault:"1KB"`
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var cfg config
+			assert.NoError(t, LoadConfig(&cfg))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLoadConfigSecretFiles(t *testing.T) {
+	type config struct {
+		User         string `env:"DB_USER"`
+		Password     string `env:"DB_PASSWORD" validate:"required"`
+		Token        string `env:"TOKEN"`
+		TLSCert      string `env:"TLS_CERT"`
+		TLSCertFile  string `env:"TLS_CERT_FILE"`
+		EmptySetting string `env:"EMPTY"`
+	}
+
+	dir := t.TempDir()
+	password := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(password, []byte("s3cr3t\n"), 0o600))
+	token := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(token, []byte("  t0k3n "), 0o600))
+
+	t.Setenv("DB_USER", "user")
+	t.Setenv("DB_PASSWORD", "overridden")
+	t.Setenv("DB_PASSWORD_FILE", password)
+	t.Setenv("TLS_CERT_FILE", "/etc/tls/cert.pem")
+	t.Setenv("EMPTY_FILE", "")
+
+	var cfg config
+	loaded, err := newConfigLoader(ConfigSecretFiles(), ConfigFlags([]string{"-token-file", token})).load(&cfg)
+	require.NoError(t, err)
+
+	require.Equal(t, "user", cfg.User)
+	require.Equal(t, "s3cr3t", cfg.Password)
+	require.Equal(t, "t0k3n", cfg.Token)
+	require.Equal(t, "", cfg.TLSCert)
+	require.Equal(t, "/etc/tls/cert.pem", cfg.TLSCertFile)
+	require.Equal(t, "secret-file:"+password, loaded.sources["DB_PASSWORD"])
+	require.ElementsMatch(t, []string{password, token}, loaded.fileNames())
+
+	t.Setenv("DB_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	require.Error(t, LoadConfig(&cfg, ConfigSecretFiles()))
+
+	cfg = config{}
+	require.NoError(t, LoadFromEnv(&cfg), "the _FILE variables are ignored without the option")
+	require.Equal(t, "overridden", cfg.Password)
+	require.Equal(t, "", cfg.Token)
+	require.Error(t, LoadConfig(&cfg, ConfigFlags([]string{"-token-file", token})), "no flag without the option")
+}
+
+func TestLoadConfigValidationErrors(t *testing.T) {
+	type config struct {
+		Port     int    `env:"PORT" validate:"min=1"`
+		Host     string `env:"HOST" validate:"required"`
+		Password string `env:"PASSWORD" validate:"min=8" secret:"true"`
+		Database struct {
+			Name string `env:"DB_NAME" validate:"oneof=users orders"`
+		}
+		Internal int `validate:"max=1"`
+	}
+
+	t.Setenv("PORT", "0")
+	t.Setenv("PASSWORD", "short")
+	t.Setenv("DB_NAME", "products")
+
+	cfg := config{Internal: 2}
+	err := LoadConfig(&cfg)
+	require.Error(t, err)
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	require.Equal(t, []ConfigFieldError{
+		{Variable: "PORT", Rule: "min=1", Value: "0"},
+		{Variable: "HOST", Rule: "required", Value: ""},
+		{Variable: "PASSWORD", Rule: "min=8", Value: "[REDACTED]"},
+		{Variable: "DB_NAME", Rule: "oneof=users orders", Value: "products"},
+		{Variable: "config.Internal", Rule: "max=1", Value: "2"},
+	}, configErr.Fields)
+	require.Equal(t, `invalid config: PORT="0" does not satisfy "min=1"; HOST is required; `+
+		`PASSWORD="[REDACTED]" does not satisfy "min=8"; DB_NAME="products" does not satisfy "oneof=users orders"; `+
+		`config.Internal="2" does not satisfy "max=1"`, err.Error())
+}
+
+type tlsConfig struct {
+	Cert   string `env:"TLS_CERT"`
+	Key    string `env:"TLS_KEY"`
+	Domain domain `env:"DOMAIN" validate:"omitempty,hostname"`
+	Region string `env:"REGION" validate:"region"`
+}
+
+type domain struct{ name string }
+
+func (d *domain) UnmarshalText(text []byte) error {
+	d.name = string(text)
+	return nil
+}
+
+func (c *tlsConfig) Validate() error {
+	if c.Region == "eu-north-1" && c.Cert == "" {
+		return fmt.Errorf("TLS is mandatory in %s", c.Region)
+	}
+	return nil
+}
+
+func TestLoadConfigParseErrors(t *testing.T) {
+	type config struct {
+		Port    int                `env:"PORT" validate:"min=1"`
+		Timeout time.Duration      `env:"TIMEOUT" envDefault:"soon"`
+		Pin     int                `env:"PIN" secret:"true"`
+		Labels  *map[string]string `env:"LABELS"`
+		Host    string             `env:"HOST" validate:"required"`
+		Token   string             `env:"TOKEN,required"`
+	}
+
+	t.Setenv("PORT", "abc")
+	t.Setenv("PIN", "12ab")
+	t.Setenv("LABELS", "team")
+
+	err := LoadConfig(&config{})
+	require.Error(t, err)
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	require.Len(t, configErr.Fields, 6)
+	assert.Equal(t, ConfigFieldError{Variable: "TOKEN", Rule: "required"}, configErr.Fields[0])
+	assert.Equal(t, "PORT", configErr.Fields[1].Variable)
+	assert.Equal(t, "abc", configErr.Fields[1].Value)
+	assert.Equal(t, "int", configErr.Fields[1].Type)
+	assert.Equal(t, ConfigFieldError{Variable: "PIN", Value: redactedConfigValue, Type: "int"}, configErr.Fields[3])
+	assert.Equal(t, `invalid config: TOKEN is required; `+
+		`PORT="abc" is not a valid int: strconv.ParseInt: parsing "abc": invalid syntax; `+
+		`TIMEOUT="soon" is not a valid time.Duration: time: invalid duration "soon"; `+
+		`PIN="[REDACTED]" is not a valid int; `+
+		`LABELS="team" is not a valid *map[string]string: invalid key-value pair "team"; `+
+		`HOST is required`, err.Error())
+	assert.NotContains(t, err.Error(), "12ab")
+}
+
+func TestLoadConfigCustomValidation(t *testing.T) {
+	opts := []ConfigOption{
+		ConfigValidation("region", func(fl validator.FieldLevel) bool {
+			return strings.HasPrefix(fl.Field().String(), "eu-")
+		}),
+		ConfigStructValidation(func(sl validator.StructLevel) {
+			c := sl.Current().Interface().(tlsConfig)
+			if c.Cert != "" && c.Key == "" {
+				sl.ReportError(c.Key, "Key", "Key", "required_with", "Cert")
+			}
+		}, tlsConfig{}),
+		ConfigCustomType(func(field reflect.Value) interface{} {
+			return field.Interface().(domain).name
+		}, domain{}),
+	}
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expectedError string
+	}{
+		{
+			name: "valid",
+			env:  map[string]string{"REGION": "eu-west-1", "DOMAIN": "example.com"},
+		},
+		{
+			name:          "custom validation",
+			env:           map[string]string{"REGION": "us-east-1"},
+			expectedError: `invalid config: REGION="us-east-1" does not satisfy "region"`,
+		},
+		{
+			name:          "struct validation",
+			env:           map[string]string{"REGION": "eu-west-1", "TLS_CERT": "cert.pem"},
+			expectedError: `invalid config: TLS_KEY="" does not satisfy "required_with=Cert"`,
+		},
+		{
+			name:          "custom type",
+			env:           map[string]string{"REGION": "eu-west-1", "DOMAIN": "not a hostname"},
+			expectedError: `invalid config: DOMAIN="not a hostname" does not satisfy "hostname"`,
+		},
+		{
+			name:          "validate method",
+			env:           map[string]string{"REGION": "eu-north-1"},
+			expectedError: "invalid config: TLS is mandatory in eu-north-1",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			var cfg tlsConfig
+			err := LoadConfig(&cfg, opts...)
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+
+	t.Run("invalid registration", func(t *testing.T) {
+		var cfg tlsConfig
+		require.Error(t, LoadConfig(&cfg, ConfigValidation("", nil)))
+	})
+}
+
+func TestWithConfig(t *testing.T) {
+	type config struct {
+		Port  int    `env:"PORT" envDefault:"8080"`
+		Token string `env:"TOKEN,required"`
+	}
+
+	t.Setenv("PORT", "1")
+	t.Setenv("BILLING_API_PORT", "2")
+	t.Setenv("BILLING_API_TOKEN", "billing")
+	t.Setenv("SHIPPING_TOKEN", "shipping")
+
+	var billing config
+	s, err := New("billing-api", "v0.0.0", WithConfig(&billing, ConfigServicePrefix()))
+	require.NoError(t, err)
+	require.Equal(t, config{Port: 2, Token: "billing"}, billing)
+	require.Same(t, &billing, s.Config())
+
+	var shipping config
+	_, err = New("shipping-api", "v0.0.0", WithConfig(&shipping, ConfigPrefix("SHIPPING_")))
+	require.NoError(t, err)
+	require.Equal(t, config{Port: 8080, Token: "shipping"}, shipping)
+
+	var unprefixed config
+	_, err = New("accounting", "v0.0.0", WithConfig(&unprefixed, ConfigServicePrefix()))
+	require.EqualError(t, err, "could not load config of service accounting: invalid config: ACCOUNTING_TOKEN is required")
+
+	require.Error(t, LoadConfig(&unprefixed, ConfigServicePrefix()))
+
+	s, err = New("no-config", "v0.0.0")
+	require.NoError(t, err)
+	require.Nil(t, s.Config())
+}
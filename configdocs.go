@@ -0,0 +1,149 @@
+package svc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// Config reference formats supported by WriteConfigReference.
+const (
+	ConfigReferenceMarkdown = "markdown"
+	ConfigReferenceJSON     = "json"
+)
+
+// ConfigVariable describes an environment variable of a configuration struct.
+type ConfigVariable struct {
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Default     *string `json:"default,omitempty"`
+	Required    bool    `json:"required"`
+	Secret      bool    `json:"secret,omitempty"`
+	Validation  string  `json:"validation,omitempty"`
+	Description string  `json:"description,omitempty"`
+}
+
+// ConfigReference returns the environment variables read by a configuration
+// struct as used with LoadConfig and the given options, of which only
+// ConfigPrefix applies. Descriptions are read from `desc` tags.
+func ConfigReference(config interface{}, opts ...ConfigOption) ([]ConfigVariable, error) {
+	l := newConfigLoader(opts...)
+	if l.prefixFromName {
+		return nil, fmt.Errorf("config service prefix requires the WithConfig option")
+	}
+	return configReference(config, l.prefix)
+}
+
+func configReference(config interface{}, prefix string) ([]ConfigVariable, error) {
+	ref := reflect.ValueOf(config)
+	if ref.Kind() != reflect.Ptr || ref.Elem().Kind() != reflect.Struct {
+		return nil, env.ErrNotAStructPtr
+	}
+
+	fields := configFields(ref.Elem())
+	variables := make([]ConfigVariable, 0, len(fields))
+	for _, f := range fields {
+		v := ConfigVariable{
+			Name:        prefix + f.key,
+			Type:        f.field.Type.String(),
+			Required:    f.isRequired(),
+			Validation:  f.field.Tag.Get("validate"),
+			Description: f.field.Tag.Get("desc"),
+		}
+		v.Secret, _ = strconv.ParseBool(f.field.Tag.Get("secret"))
+		if f.hasDefault {
+			def := f.defValue
+			v.Default = &def
+		}
+		variables = append(variables, v)
+	}
+	return variables, nil
+}
+
+// WriteConfigReference writes the reference of the environment variables read
+// by a configuration struct, see ConfigReference, in the given format,
+// ConfigReferenceMarkdown or ConfigReferenceJSON.
+func WriteConfigReference(w io.Writer, config interface{}, format string, opts ...ConfigOption) error {
+	variables, err := ConfigReference(config, opts...)
+	if err != nil {
+		return err
+	}
+	return writeConfigReference(w, variables, format)
+}
+
+func writeConfigReference(w io.Writer, variables []ConfigVariable, format string) error {
+	switch format {
+	case ConfigReferenceMarkdown:
+		return writeConfigReferenceMarkdown(w, variables)
+	case ConfigReferenceJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(variables)
+	default:
+		return fmt.Errorf("unsupported config reference format %q", format)
+	}
+}
+
+func writeConfigReferenceMarkdown(w io.Writer, variables []ConfigVariable) error {
+	var b strings.Builder
+	b.WriteString("| Variable | Type | Default | Required | Validation | Description |\n")
+	b.WriteString("|----------|------|---------|----------|------------|-------------|\n")
+	for _, v := range variables {
+		def := ""
+		switch {
+		case v.Default == nil:
+		case *v.Default == "":
+			def = "`\"\"`"
+		default:
+			def = markdownCode(*v.Default)
+		}
+		required := "no"
+		if v.Required {
+			required = "yes"
+		}
+		description := v.Description
+		if v.Secret {
+			description = strings.TrimSpace("(secret) " + description)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCode(v.Name),
+			markdownCode(v.Type),
+			def,
+			required,
+			markdownCode(v.Validation),
+			markdownEscape(description),
+		)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownEscape(s) + "`"
+}
+
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// isRequired returns whether the field must be set, either by the `required`
+// env tag option or the `required` validation rule.
+func (f configField) isRequired() bool {
+	if f.hasOption("required") {
+		return true
+	}
+	for _, rule := range strings.Split(f.field.Tag.Get("validate"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
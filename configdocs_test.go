@@ -0,0 +1,66 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type referenceConfig struct {
+	Port     int           `env:"PORT" envDefault:"8080" validate:"min=1,max=65535" desc:"Port to listen on."`
+	Timeout  time.Duration `env:"TIMEOUT,required" desc:"Request | response timeout."`
+	Password string        `env:"PASSWORD" validate:"required" secret:"true"`
+	Prefix   string        `env:"PREFIX" envDefault:""`
+	Nested   struct {
+		Tags []string `env:"TAGS"`
+	}
+}
+
+func TestConfigReference(t *testing.T) {
+	variables, err := ConfigReference(&referenceConfig{})
+	require.NoError(t, err)
+
+	port, empty := "8080", ""
+	assert.Equal(t, []ConfigVariable{
+		{Name: "PORT", Type: "int", Default: &port, Validation: "min=1,max=65535", Description: "Port to listen on."},
+		{Name: "TIMEOUT", Type: "time.Duration", Required: true, Description: "Request | response timeout."},
+		{Name: "PASSWORD", Type: "string", Required: true, Secret: true, Validation: "required"},
+		{Name: "PREFIX", Type: "string", Default: &empty},
+		{Name: "TAGS", Type: "[]string"},
+	}, variables)
+
+	_, err = ConfigReference(referenceConfig{})
+	require.Error(t, err)
+
+	variables, err = ConfigReference(&referenceConfig{}, ConfigPrefix("BILLING_"))
+	require.NoError(t, err)
+	assert.Equal(t, "BILLING_PORT", variables[0].Name)
+	assert.Equal(t, "BILLING_TAGS", variables[4].Name)
+
+	_, err = ConfigReference(&referenceConfig{}, ConfigServicePrefix())
+	require.Error(t, err)
+}
+
+func TestWriteConfigReference(t *testing.T) {
+	var md bytes.Buffer
+	require.NoError(t, WriteConfigReference(&md, &referenceConfig{}, ConfigReferenceMarkdown))
+	assert.Equal(t, "| Variable | Type | Default | Required | Validation | Description |\n"+
+		"|----------|------|---------|----------|------------|-------------|\n"+
+		"| `PORT` | `int` | `8080` | no | `min=1,max=65535` | Port to listen on. |\n"+
+		"| `TIMEOUT` | `time.Duration` |  | yes |  | Request \\| response timeout. |\n"+
+		"| `PASSWORD` | `string` |  | yes | `required` | (secret) |\n"+
+		"| `PREFIX` | `string` | `\"\"` | no |  |  |\n"+
+		"| `TAGS` | `[]string` |  | no |  |  |\n", md.String())
+
+	var js bytes.Buffer
+	require.NoError(t, WriteConfigReference(&js, &referenceConfig{}, ConfigReferenceJSON))
+	var variables []ConfigVariable
+	require.NoError(t, json.Unmarshal(js.Bytes(), &variables))
+	assert.Len(t, variables, 5)
+
+	require.Error(t, WriteConfigReference(&js, &referenceConfig{}, "html"))
+}
@@ -0,0 +1,76 @@
+package svc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const redactedConfigValue = "[REDACTED]"
+
+// configEntry is a configuration value as dumped by the config handler.
+type configEntry struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source,omitempty"`
+}
+
+// isSecret returns whether the field's value must not be exposed. Fields are
+// secret if tagged with `secret:"true"` or if read from a secret file.
+func (c *loadedConfig) isSecret(f configField) bool {
+	if secret, _ := strconv.ParseBool(f.field.Tag.Get("secret")); secret {
+		return true
+	}
+	return strings.HasPrefix(c.sources[f.key], configSourceSecret+":")
+}
+
+// entries returns the redacted configuration values and their sources.
+func (c *loadedConfig) entries() []configEntry {
+	entries := make([]configEntry, 0, len(c.fields))
+	for _, f := range c.fields {
+		entry := configEntry{Key: c.variable(f), Source: c.sources[f.key]}
+		stringer, isStringer := f.value.Interface().(fmt.Stringer)
+		if !isStringer && f.value.CanAddr() {
+			// Types like url.URL implement fmt.Stringer on their pointer.
+			stringer, isStringer = f.value.Addr().Interface().(fmt.Stringer)
+		}
+		switch {
+		case c.isSecret(f) && !f.value.IsZero():
+			entry.Value = redactedConfigValue
+		case f.value.Kind() == reflect.Ptr && f.value.IsNil():
+			entry.Value = nil
+		case isStringer && f.value.Kind() != reflect.String:
+			entry.Value = stringer.String()
+		default:
+			entry.Value = f.value.Interface()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// serveConfig serves the redacted configuration as JSON.
+func (s *SVC) serveConfig(w http.ResponseWriter, _ *http.Request) {
+	_, loaded := s.config.current()
+	b, err := json.Marshal(loaded.entries())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func (s *SVC) logConfig() {
+	_, loaded := s.config.current()
+	fields := make([]zap.Field, 0, len(loaded.fields))
+	for _, e := range loaded.entries() {
+		fields = append(fields, zap.Dict(e.Key, zap.Any("value", e.Value), zap.String("source", e.Source)))
+	}
+	s.logger.Info("Loaded config", zap.Dict("config", fields...))
+}
@@ -0,0 +1,70 @@
+package svc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestConfigHandler(t *testing.T) {
+	type config struct {
+		Host     string        `env:"HOST"`
+		Timeout  time.Duration `env:"TIMEOUT" envDefault:"5s"`
+		Password string        `env:"PASSWORD" secret:"true"`
+		Token    string        `env:"TOKEN"`
+		Empty    string        `env:"EMPTY_SECRET" secret:"true"`
+	}
+
+	token := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(token, []byte("t0k3n"), 0o600))
+	t.Setenv("HOST", "example.com")
+	t.Setenv("PASSWORD", "s3cr3t")
+	t.Setenv("TOKEN_FILE", token)
+
+	core, logs := observer.New(zap.InfoLevel)
+	var cfg config
+	s, err := New("dummy-service", "v0.0.0",
+		WithLogger(zap.New(core), zap.NewAtomicLevel()),
+		WithConfig(&cfg, ConfigSecretFiles()),
+		WithConfigHandler(),
+	)
+	require.NoError(t, err)
+
+	expected := []configEntry{
+		{Key: "HOST", Value: "example.com", Source: "env"},
+		{Key: "TIMEOUT", Value: "5s", Source: "default"},
+		{Key: "PASSWORD", Value: "[REDACTED]", Source: "env"},
+		{Key: "TOKEN", Value: "[REDACTED]", Source: "secret-file:" + token},
+		{Key: "EMPTY_SECRET", Value: ""},
+	}
+
+	req := httptest.NewRequest("GET", "/debug/config", nil)
+	rec := httptest.NewRecorder()
+	s.Router.ServeHTTP(rec, req)
+	require.Equal(t, 200, rec.Code)
+
+	var entries []configEntry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	assert.Equal(t, expected, entries)
+
+	s.Run()
+	configLogs := logs.FilterMessage("Loaded config").All()
+	require.Len(t, configLogs, 1)
+	assert.NotContains(t, fmt.Sprint(configLogs[0].ContextMap()), "s3cr3t")
+	assert.Equal(t, map[string]interface{}{"value": "[REDACTED]", "source": "env"},
+		configLogs[0].ContextMap()["config"].(map[string]interface{})["PASSWORD"])
+}
+
+func TestConfigHandlerRequiresConfig(t *testing.T) {
+	_, err := New("dummy-service", "v0.0.0", WithConfigHandler())
+	require.Error(t, err)
+}
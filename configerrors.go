@@ -0,0 +1,125 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ConfigError is returned when loading a configuration fails validation. It
+// holds all invalid fields.
+type ConfigError struct {
+	Fields []ConfigFieldError
+}
+
+// Error implements the error interface.
+func (e *ConfigError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// ConfigFieldError describes a configuration field failing validation.
+type ConfigFieldError struct {
+	// Variable is the environment variable of the field, or the field's
+	// namespace if it is not read from an environment variable.
+	Variable string
+	// Rule is the validation rule that failed, e.g. `min=1`.
+	Rule string
+	// Value is the offending value, redacted for secret fields.
+	Value string
+	// Type is the field's type if the value could not be parsed as such.
+	Type string
+	// Err is the error parsing the value, omitted for secret fields as it may
+	// contain the value.
+	Err error
+}
+
+// Error implements the error interface.
+func (e ConfigFieldError) Error() string {
+	switch {
+	case e.Type != "" && e.Err != nil:
+		return fmt.Sprintf("%s=%q is not a valid %s: %v", e.Variable, e.Value, e.Type, e.Err)
+	case e.Type != "":
+		return fmt.Sprintf("%s=%q is not a valid %s", e.Variable, e.Value, e.Type)
+	case e.Rule == "required":
+		return fmt.Sprintf("%s is required", e.Variable)
+	}
+	return fmt.Sprintf("%s=%q does not satisfy %q", e.Variable, e.Value, e.Rule)
+}
+
+// Unwrap returns the error parsing the value, if any.
+func (e ConfigFieldError) Unwrap() error {
+	return e.Err
+}
+
+// addValidationErrors translates the errors returned by the validator into
+// errors naming the environment variables, and adds those of variables
+// without error yet to configErr.
+func (c *loadedConfig) addValidationErrors(configErr *ConfigError, err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make(map[string]configField, len(c.fields))
+	for _, f := range c.fields {
+		fields[f.namespace] = f
+	}
+	invalid := make(map[string]bool, len(configErr.Fields))
+	for _, fe := range configErr.Fields {
+		invalid[fe.Variable] = true
+	}
+
+	for _, fe := range validationErrs {
+		fieldErr := ConfigFieldError{
+			Variable: fe.StructNamespace(),
+			Rule:     fe.Tag(),
+			Value:    fmt.Sprint(fe.Value()),
+		}
+		if fe.Param() != "" {
+			fieldErr.Rule += "=" + fe.Param()
+		}
+		if f, ok := fields[fe.StructNamespace()]; ok {
+			fieldErr.Variable = c.variable(f)
+			if c.isSecret(f) {
+				fieldErr.Value = redactedConfigValue
+			}
+		}
+		if !invalid[fieldErr.Variable] {
+			configErr.Fields = append(configErr.Fields, fieldErr)
+		}
+	}
+	return nil
+}
+
+// parseError returns the error of a field whose value could not be parsed.
+func (c *loadedConfig) parseError(f configField, value string, err error) ConfigFieldError {
+	fieldErr := ConfigFieldError{
+		Variable: c.variable(f),
+		Value:    value,
+		Type:     f.field.Type.String(),
+		Err:      err,
+	}
+	if c.isSecret(f) {
+		fieldErr.Value = redactedConfigValue
+		fieldErr.Err = nil
+	}
+	return fieldErr
+}
+
+// checkRequired returns an error for all fields with the `required` env tag
+// option that are neither set nor have a default.
+func (c *loadedConfig) checkRequired() []ConfigFieldError {
+	var errs []ConfigFieldError
+	for _, f := range c.fields {
+		if _, set := c.sources[f.key]; !set && f.hasOption("required") {
+			errs = append(errs, ConfigFieldError{Variable: c.variable(f), Rule: "required"})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,130 @@
+package svc
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// setFields sets the fields of the configuration to their values in the
+// environment or their defaults, following the tag options of caarlos0/env.
+// It returns an error for every value that could not be parsed, leaving its
+// field untouched.
+func (c *loadedConfig) setFields(environment map[string]string, parsers map[reflect.Type]env.ParserFunc) []ConfigFieldError {
+	var errs []ConfigFieldError
+	for _, f := range c.fields {
+		value, ok := environment[f.key]
+		if !ok {
+			if !f.hasDefault {
+				continue
+			}
+			value = f.defValue
+		}
+		if strings.EqualFold(f.field.Tag.Get("envExpand"), "true") {
+			value = os.ExpandEnv(value)
+		}
+		if f.hasOption("unset") {
+			_ = os.Unsetenv(c.variable(f))
+		}
+		if f.hasOption("notEmpty") && value == "" {
+			errs = append(errs, ConfigFieldError{Variable: c.variable(f), Rule: "notEmpty"})
+			continue
+		}
+		if f.hasOption("file") && value != "" {
+			b, err := os.ReadFile(value)
+			if err != nil {
+				errs = append(errs, c.parseError(f, value, err))
+				continue
+			}
+			value = string(b)
+		}
+		if value == "" {
+			continue
+		}
+
+		v, err := parseConfigValue(f.field.Type, value, f.separator, parsers)
+		if err != nil {
+			errs = append(errs, c.parseError(f, value, err))
+			continue
+		}
+		f.value.Set(v)
+	}
+	return errs
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// parseConfigValue parses a value of the given type. In order of precedence,
+// the type is parsed as an encoding.TextUnmarshaler, by its custom parser,
+// as a time.Duration or a basic kind. Pointers and slices are parsed by their
+// element type, slice elements being split by the separator.
+func parseConfigValue(typ reflect.Type, value, separator string, parsers map[reflect.Type]env.ParserFunc) (reflect.Value, error) {
+	if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		v := reflect.New(typ)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return reflect.Value{}, err
+		}
+		return v.Elem(), nil
+	}
+	if parser, ok := parsers[typ]; ok {
+		parsed, err := parser(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v := reflect.ValueOf(parsed)
+		if !v.IsValid() || !v.Type().AssignableTo(typ) {
+			return reflect.Value{}, fmt.Errorf("parser of %s returned a %T", typ, parsed)
+		}
+		return v, nil
+	}
+	if typ == durationType {
+		d, err := time.ParseDuration(value)
+		return reflect.ValueOf(d), err
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		elem, err := parseConfigValue(typ.Elem(), value, separator, parsers)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v := reflect.New(typ.Elem())
+		v.Elem().Set(elem)
+		return v, nil
+	case reflect.Slice:
+		parts := strings.Split(value, separator)
+		v := reflect.MakeSlice(typ, len(parts), len(parts))
+		for i, part := range parts {
+			elem, err := parseConfigValue(typ.Elem(), part, separator, parsers)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(elem)
+		}
+		return v, nil
+	case reflect.String:
+		return reflect.ValueOf(value).Convert(typ), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		return reflect.ValueOf(b).Convert(typ), err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, typ.Bits())
+		return reflect.ValueOf(i).Convert(typ), err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, typ.Bits())
+		return reflect.ValueOf(u).Convert(typ), err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, typ.Bits())
+		return reflect.ValueOf(f).Convert(typ), err
+	}
+	return reflect.Value{}, fmt.Errorf("no parser found for type %s", typ)
+}
@@ -0,0 +1,82 @@
+package svc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseConfigValue(t *testing.T) {
+	type level string
+	tests := []struct {
+		value    string
+		expected interface{}
+	}{
+		{value: "text", expected: "text"},
+		{value: "warn", expected: level("warn")},
+		{value: "true", expected: true},
+		{value: "-42", expected: int8(-42)},
+		{value: "42", expected: uint(42)},
+		{value: "1.5", expected: float32(1.5)},
+		{value: "90s", expected: 90 * time.Second},
+		{value: "error", expected: zapcore.ErrorLevel},
+		{value: "1KiB", expected: KiB},
+		{value: "a,b", expected: []string{"a", "b"}},
+		{value: "1s,2s", expected: []time.Duration{time.Second, 2 * time.Second}},
+	}
+	for _, tt := range tests {
+		v, err := parseConfigValue(reflect.TypeOf(tt.expected), tt.value, ",", DefaultParsers())
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, v.Interface(), tt.value)
+	}
+
+	v, err := parseConfigValue(reflect.TypeOf([]*int{}), "1;2", ";", nil)
+	require.NoError(t, err)
+	ints := v.Interface().([]*int)
+	require.Len(t, ints, 2)
+	assert.Equal(t, 2, *ints[1])
+
+	for typ, value := range map[reflect.Type]string{
+		reflect.TypeOf(int8(0)):          "300",
+		reflect.TypeOf(false):            "maybe",
+		reflect.TypeOf(zapcore.Level(0)): "loud",
+		reflect.TypeOf(struct{}{}):       "{}",
+	} {
+		_, err := parseConfigValue(typ, value, ",", nil)
+		assert.Error(t, err, typ.String())
+	}
+}
+
+func TestLoadConfigTagOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("s3cr3t"), 0o600))
+
+	type config struct {
+		Token    string `env:"TOKEN,file"`
+		Home     string `env:"HOME_DIR" envExpand:"true"`
+		Unset    string `env:"UNSET,unset"`
+		NotEmpty string `env:"NOT_EMPTY,notEmpty"`
+	}
+	t.Setenv("TOKEN", path)
+	t.Setenv("BASE", "/home")
+	t.Setenv("HOME_DIR", "$BASE/user")
+	t.Setenv("UNSET", "value")
+	t.Setenv("NOT_EMPTY", "set")
+
+	var cfg config
+	require.NoError(t, LoadConfig(&cfg))
+	assert.Equal(t, "s3cr3t", cfg.Token)
+	assert.Equal(t, "/home/user", cfg.Home)
+	assert.Equal(t, "value", cfg.Unset)
+	_, ok := os.LookupEnv("UNSET")
+	assert.False(t, ok)
+
+	t.Setenv("NOT_EMPTY", "")
+	assert.EqualError(t, LoadConfig(&config{}), `invalid config: NOT_EMPTY="" does not satisfy "notEmpty"`)
+}
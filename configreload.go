@@ -0,0 +1,151 @@
+package svc
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const configWatcherWorkerName = "config-watcher"
+
+// serviceConfig holds the configuration registered with WithConfig.
+type serviceConfig struct {
+	mu     sync.RWMutex
+	value  interface{}
+	loaded *loadedConfig
+	loader *configLoader
+	// loadErr is the error of a configuration loaded leniently for the
+	// config command.
+	loadErr error
+	// checkedFiles are the files as last checked by filesChanged, or as read
+	// by the last reload.
+	checkedFiles map[string]fileState
+}
+
+func (c *serviceConfig) current() (interface{}, *loadedConfig) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.value, c.loaded
+}
+
+// reload loads the configuration into a new value. The current configuration
+// is only replaced if the new one is valid.
+func (c *serviceConfig) reload() (old, new interface{}, err error) {
+	c.mu.RLock()
+	old = c.value
+	c.mu.RUnlock()
+
+	new = reflect.New(reflect.TypeOf(old).Elem()).Interface()
+	loaded, err := c.loader.load(new)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	c.value = new
+	c.loaded = loaded
+	c.checkedFiles = loaded.files
+	c.mu.Unlock()
+
+	return old, new, nil
+}
+
+// filesChanged returns whether any of the files the current configuration was
+// read from changed since they were last checked. Changes are only reported
+// once, so that an invalid file gets rejected once rather than on every check.
+func (c *serviceConfig) filesChanged() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.checkedFiles == nil {
+		c.checkedFiles = c.loaded.files
+	}
+	files := statFiles(c.loaded.fileNames())
+	changed := !reflect.DeepEqual(c.checkedFiles, files)
+	c.checkedFiles = files
+	return changed
+}
+
+var _ Worker = (*configWatcher)(nil)
+
+// configWatcher is the worker reloading the service's configuration.
+type configWatcher struct {
+	svc      *SVC
+	interval time.Duration
+	logger   *zap.Logger
+
+	signals chan os.Signal
+	stop    chan struct{}
+}
+
+func newConfigWatcher(s *SVC, interval time.Duration) *configWatcher {
+	return &configWatcher{
+		svc:      s,
+		interval: interval,
+		signals:  make(chan os.Signal, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Init implements the Worker interface.
+func (w *configWatcher) Init(logger *zap.Logger) error {
+	w.logger = logger
+	signal.Notify(w.signals, syscall.SIGHUP)
+
+	return nil
+}
+
+// Run implements the Worker interface.
+func (w *configWatcher) Run() error {
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			return nil
+		case sig := <-w.signals:
+			w.logger.Info("Reloading config", zap.String("signal", sig.String()))
+			w.reload()
+		case <-tick:
+			if w.svc.config.filesChanged() {
+				w.logger.Info("Reloading config after file change")
+				w.reload()
+			}
+		}
+	}
+}
+
+// Terminate implements the Worker interface.
+func (w *configWatcher) Terminate() error {
+	signal.Stop(w.signals)
+	close(w.stop)
+
+	return nil
+}
+
+func (w *configWatcher) reload() {
+	old, new, err := w.svc.config.reload()
+	if err != nil {
+		w.logger.Error("Rejected invalid config", zap.Error(err))
+		return
+	}
+
+	for _, name := range w.svc.workersAdded {
+		if r, ok := w.svc.workers[name].(Reloader); ok {
+			if err := r.Reload(old, new); err != nil {
+				w.logger.Error("Worker could not reload config", zap.String("worker", name), zap.Error(err))
+			}
+		}
+	}
+	w.logger.Info("Config reloaded")
+}
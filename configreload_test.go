@@ -0,0 +1,132 @@
+package svc
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type reloadConfig struct {
+	Level string `env:"LEVEL" validate:"oneof=debug info"`
+}
+
+type reloaderMock struct {
+	WorkerMock
+	reloads chan [2]*reloadConfig
+}
+
+func (r *reloaderMock) Reload(old, new interface{}) error {
+	r.reloads <- [2]*reloadConfig{old.(*reloadConfig), new.(*reloadConfig)}
+	return nil
+}
+
+func TestConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	modTime := time.Now()
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		modTime = modTime.Add(time.Second)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	writeConfig("LEVEL: info\n")
+
+	core, logs := observer.New(zap.InfoLevel)
+	var cfg reloadConfig
+	s, err := New("dummy-service", "v0.0.0",
+		WithLogger(zap.New(core), zap.NewAtomicLevel()),
+		WithConfig(&cfg, ConfigFiles(path)),
+		WithConfigReload(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "info", cfg.Level)
+
+	termWorkerCh := make(chan struct{})
+	reloader := &reloaderMock{
+		WorkerMock: WorkerMock{
+			InitFunc:      func(*zap.Logger) error { return nil },
+			RunFunc:       func() error { <-termWorkerCh; return nil },
+			TerminateFunc: func() error { close(termWorkerCh); return nil },
+		},
+		reloads: make(chan [2]*reloadConfig, 1),
+	}
+	s.AddWorker("reloader", reloader)
+
+	termSvcCh := make(chan struct{})
+	go func() { s.Run(); close(termSvcCh) }()
+
+	awaitReload := func() [2]*reloadConfig {
+		select {
+		case r := <-reloader.reloads:
+			return r
+		case <-time.After(3 * time.Second):
+			require.FailNow(t, "Config has not been reloaded")
+			return [2]*reloadConfig{}
+		}
+	}
+
+	writeConfig("LEVEL: debug\n")
+	r := awaitReload()
+	assert.Equal(t, "info", r[0].Level)
+	assert.Equal(t, "debug", r[1].Level)
+	assert.Equal(t, "info", cfg.Level, "initial config is left untouched")
+
+	writeConfig("LEVEL: invalid\n")
+	require.Eventually(t, func() bool {
+		return logs.FilterMessage("Rejected invalid config").Len() > 0
+	}, 3*time.Second, time.Millisecond, "Invalid config has not been rejected")
+	select {
+	case <-reloader.reloads:
+		require.FailNow(t, "Invalid config has been reloaded")
+	default:
+	}
+	assert.Equal(t, "debug", s.Config().(*reloadConfig).Level)
+
+	writeConfig("LEVEL: info\n")
+	s.Signal(syscall.SIGHUP)
+	r = awaitReload()
+	assert.Equal(t, "debug", r[0].Level)
+	assert.Equal(t, "info", r[1].Level)
+
+	s.Shutdown()
+	select {
+	case <-termSvcCh:
+	case <-time.After(3 * time.Second):
+		require.FailNow(t, "Service has not been shut down")
+	}
+}
+
+func TestConfigFilesChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("LEVEL: info\n"), 0o600))
+
+	s, err := New("dummy-service", "v0.0.0", WithConfig(&reloadConfig{}, ConfigFiles(path)))
+	require.NoError(t, err)
+	c := s.config
+	assert.False(t, c.filesChanged())
+
+	require.NoError(t, os.WriteFile(path, []byte("LEVEL: invalid\n"), 0o600))
+	assert.True(t, c.filesChanged())
+	_, _, err = c.reload()
+	require.Error(t, err)
+	assert.False(t, c.filesChanged(), "invalid file is rejected once")
+	assert.False(t, c.filesChanged(), "invalid file is rejected once")
+
+	require.NoError(t, os.WriteFile(path, []byte("LEVEL: debug\n"), 0o600))
+	assert.True(t, c.filesChanged())
+	_, _, err = c.reload()
+	require.NoError(t, err)
+	assert.False(t, c.filesChanged())
+	assert.Equal(t, "debug", s.Config().(*reloadConfig).Level)
+}
+
+func TestConfigReloadRequiresConfig(t *testing.T) {
+	_, err := New("dummy-service", "v0.0.0", WithConfigReload(time.Second))
+	require.Error(t, err)
+}
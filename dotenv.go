@@ -0,0 +1,127 @@
+package svc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ConfigDotEnv is a config option that reads environment variables from the
+// given .env files, e.g. for local development. Missing files are skipped and
+// later files take precedence over earlier ones. By default, variables set in
+// the process' environment take precedence over .env files; see
+// ConfigDotEnvOverride.
+//
+// Each line of a .env file holds a `KEY=value` pair, optionally preceded by
+// `export`. Values can be single or double quoted; double quoted values
+// support `\n`, `\t`, `\"` and `\\` escapes. Lines starting with `#` and
+// unquoted text after ` #` are comments.
+func ConfigDotEnv(paths ...string) ConfigOption {
+	return func(l *configLoader) {
+		l.dotEnvFiles = append(l.dotEnvFiles, paths...)
+	}
+}
+
+// ConfigDotEnvOverride is a config option that lets variables read from .env
+// files take precedence over the process' environment.
+func ConfigDotEnvOverride() ConfigOption {
+	return func(l *configLoader) {
+		l.dotEnvOverride = true
+	}
+}
+
+// readDotEnvFiles reads the given .env files, skipping missing ones.
+func readDotEnvFiles(paths []string) (map[string]configValue, error) {
+	values := map[string]configValue{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dotenv file: %w", err)
+		}
+		fileValues, err := parseDotEnv(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("dotenv file %s: %w", path, err)
+		}
+		for k, v := range fileValues {
+			values[k] = configValue{value: v, source: configSourceDotEnv + ":" + path}
+		}
+	}
+	return values, nil
+}
+
+func parseDotEnv(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable %q", n, line)
+		}
+		value, err := parseDotEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+func parseDotEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch quote := value[0]; quote {
+	case '\'', '"':
+		end := strings.IndexByte(value[1:], quote)
+		for quote == '"' && end > 0 && isEscaped(value[1:], end) {
+			next := strings.IndexByte(value[end+2:], quote)
+			if next < 0 {
+				end = -1
+				break
+			}
+			end += next + 1
+		}
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value %s", value)
+		}
+		if rest := strings.TrimSpace(value[end+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected characters after quoted value %s", value)
+		}
+		value = value[1 : end+1]
+		if quote == '"' {
+			value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value)
+		}
+		return value, nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
+
+// isEscaped returns whether the byte at index i of s is preceded by an odd
+// number of backslashes.
+func isEscaped(s string, i int) bool {
+	backslashes := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		backslashes++
+	}
+	return backslashes%2 == 1
+}
@@ -0,0 +1,75 @@
+package svc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	values, err := parseDotEnv(strings.NewReader(`
+# comment
+HOST=localhost
+export PORT = 8080 # inline comment
+EMPTY=
+SINGLE='a # not a comment\n'
+DOUBLE="line1\nline2 \"quoted\"" # comment
+URL=http://example.com/#anchor
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"HOST":   "localhost",
+		"PORT":   "8080",
+		"EMPTY":  "",
+		"SINGLE": `a # not a comment\n`,
+		"DOUBLE": "line1\nline2 \"quoted\"",
+		"URL":    "http://example.com/#anchor",
+	}, values)
+
+	for _, invalid := range []string{"NOVALUE", "=value", "A B=c", `QUOTE="unterminated`, `QUOTE="a" b`} {
+		_, err := parseDotEnv(strings.NewReader(invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestLoadConfigDotEnv(t *testing.T) {
+	type config struct {
+		Host     string `env:"HOST" envDefault:"localhost"`
+		Port     int    `env:"PORT"`
+		LogLevel string `env:"LOG_LEVEL"`
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	dir := t.TempDir()
+	password := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(password, []byte("s3cr3t"), 0o600))
+	dotEnv := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(dotEnv, []byte("APP_PORT=9090\nAPP_LOG_LEVEL=debug\nAPP_DB_PASSWORD_FILE="+password+"\n"), 0o600))
+	local := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(local, []byte("APP_PORT=9191\n"), 0o600))
+
+	t.Setenv("APP_LOG_LEVEL", "error")
+
+	opts := []ConfigOption{ConfigPrefix("APP_"), ConfigSecretFiles(), ConfigDotEnv(dotEnv, filepath.Join(dir, "missing.env"), local)}
+
+	var cfg config
+	loaded, err := newConfigLoader(opts...).load(&cfg)
+	require.NoError(t, err)
+	require.Equal(t, config{Host: "localhost", Port: 9191, LogLevel: "error", Password: "s3cr3t"}, cfg)
+	require.Equal(t, "dotenv:"+local, loaded.sources["PORT"])
+	require.Equal(t, "env", loaded.sources["LOG_LEVEL"])
+	require.Contains(t, loaded.fileNames(), dotEnv)
+
+	cfg = config{}
+	loaded, err = newConfigLoader(append(opts, ConfigDotEnvOverride())...).load(&cfg)
+	require.NoError(t, err)
+	require.Equal(t, "debug", cfg.LogLevel)
+	require.Equal(t, "dotenv:"+dotEnv, loaded.sources["LOG_LEVEL"])
+
+	invalid := filepath.Join(dir, "invalid.env")
+	require.NoError(t, os.WriteFile(invalid, []byte("APP_PORT\n"), 0o600))
+	require.Error(t, LoadConfig(&cfg, ConfigDotEnv(invalid)))
+}
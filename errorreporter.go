@@ -0,0 +1,466 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultErrorReportLevel       = zapcore.ErrorLevel
+	defaultErrorReportRateLimit   = 10
+	defaultErrorReportRatePeriod  = time.Minute
+	defaultErrorReportDedupWindow = time.Minute
+	defaultErrorReportTimeout     = 5 * time.Second
+
+	errorReporterWorkerName = "error-reporter"
+	errorReportQueueSize    = 100
+	errorReportDedupMaxKeys = 1024
+)
+
+// ErrorReport is a log entry forwarded to an ErrorReporter.
+type ErrorReport struct {
+	Time       time.Time `json:"time"`
+	Level      string    `json:"level"`
+	LoggerName string    `json:"logger,omitempty"`
+	Message    string    `json:"message"`
+	Caller     string    `json:"caller,omitempty"`
+	Stack      string    `json:"stacktrace,omitempty"`
+	// Error is the message of the entry's `error` field, if any.
+	Error string `json:"error,omitempty"`
+	// Fields are the entry's fields, including the logger's, encoded as a
+	// JSON object.
+	Fields  json.RawMessage `json:"fields,omitempty"`
+	Service string          `json:"service"`
+	Version string          `json:"version"`
+	// Count is the number of occurrences of this entry since it was last
+	// reported, including this one.
+	Count int `json:"count"`
+}
+
+// ErrorReporter forwards log entries to an external error collector.
+type ErrorReporter interface {
+	Report(ctx context.Context, report ErrorReport) error
+}
+
+// ErrorReporterOption defines the option type of WithErrorReporter.
+type ErrorReporterOption func(*errorReporting)
+
+// ErrorReportLevel sets the minimum level of the reported log entries.
+// Defaults to error.
+func ErrorReportLevel(level zapcore.Level) ErrorReporterOption {
+	return func(r *errorReporting) {
+		r.level = level
+	}
+}
+
+// ErrorReportRateLimit limits the number of reports per period. Defaults to
+// 10 reports per minute.
+func ErrorReportRateLimit(limit int, period time.Duration) ErrorReporterOption {
+	return func(r *errorReporting) {
+		r.rateLimit = limit
+		r.ratePeriod = period
+	}
+}
+
+// ErrorReportDedupWindow sets the window within which entries with the same
+// message and caller are only reported once. Defaults to one minute.
+func ErrorReportDedupWindow(d time.Duration) ErrorReporterOption {
+	return func(r *errorReporting) {
+		r.dedupWindow = d
+	}
+}
+
+// ErrorReportTimeout sets the timeout of a single report. Defaults to 5s.
+func ErrorReportTimeout(d time.Duration) ErrorReporterOption {
+	return func(r *errorReporting) {
+		r.timeout = d
+	}
+}
+
+type dedupState struct {
+	lastReported time.Time
+	suppressed   int
+}
+
+var _ Worker = (*errorReporting)(nil)
+
+// errorReporting is the worker forwarding log entries, queued by an
+// errorReportCore, to an ErrorReporter.
+type errorReporting struct {
+	reporter ErrorReporter
+	service  string
+	version  string
+	logger   *zap.Logger
+
+	level       zapcore.Level
+	rateLimit   int
+	ratePeriod  time.Duration
+	dedupWindow time.Duration
+	timeout     time.Duration
+
+	mu         sync.Mutex
+	rateStart  time.Time
+	rateCount  int
+	dedup      map[string]*dedupState
+	reports    chan ErrorReport
+	stop       chan struct{}
+	stopOnce   sync.Once
+	runDone    chan struct{}
+	terminated bool
+}
+
+func newErrorReporting(reporter ErrorReporter, service, version string, opts ...ErrorReporterOption) *errorReporting {
+	r := &errorReporting{
+		reporter: reporter,
+		service:  service,
+		version:  version,
+
+		level:       defaultErrorReportLevel,
+		rateLimit:   defaultErrorReportRateLimit,
+		ratePeriod:  defaultErrorReportRatePeriod,
+		dedupWindow: defaultErrorReportDedupWindow,
+		timeout:     defaultErrorReportTimeout,
+
+		dedup:   map[string]*dedupState{},
+		reports: make(chan ErrorReport, errorReportQueueSize),
+		stop:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// enqueue queues a log entry to be reported. It never blocks; if the queue is
+// full, the entry is dropped.
+func (r *errorReporting) enqueue(e zapcore.Entry, errMsg string, fields json.RawMessage) {
+	count, ok := r.admit(e)
+	if !ok {
+		return
+	}
+
+	stack := e.Stack
+	if stack == "" {
+		stack = callerStack()
+	}
+	report := ErrorReport{
+		Time:       e.Time,
+		Level:      e.Level.String(),
+		LoggerName: e.LoggerName,
+		Message:    e.Message,
+		Stack:      stack,
+		Error:      errMsg,
+		Fields:     fields,
+		Service:    r.service,
+		Version:    r.version,
+		Count:      count,
+	}
+	if e.Caller.Defined {
+		report.Caller = e.Caller.TrimmedPath()
+	}
+
+	select {
+	case r.reports <- report:
+	default:
+	}
+}
+
+// admit applies deduplication and rate limiting to an entry. It returns
+// whether the entry should be reported and how many occurrences it accounts
+// for.
+func (r *errorReporting) admit(e zapcore.Entry) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := e.Time
+	key := e.Message + "\x00" + e.Caller.String()
+	state, seen := r.dedup[key]
+	if seen && now.Sub(state.lastReported) < r.dedupWindow {
+		state.suppressed++
+		return 0, false
+	}
+
+	if now.Sub(r.rateStart) >= r.ratePeriod {
+		r.rateStart = now
+		r.rateCount = 0
+	}
+	if r.rateCount >= r.rateLimit {
+		return 0, false
+	}
+	r.rateCount++
+
+	if !seen {
+		if len(r.dedup) >= errorReportDedupMaxKeys {
+			r.pruneDedup(now)
+		}
+		if len(r.dedup) >= errorReportDedupMaxKeys {
+			r.evictOldestDedup()
+		}
+		state = &dedupState{}
+		r.dedup[key] = state
+	}
+	count := state.suppressed + 1
+	state.lastReported = now
+	state.suppressed = 0
+
+	return count, true
+}
+
+func (r *errorReporting) pruneDedup(now time.Time) {
+	for key, state := range r.dedup {
+		if now.Sub(state.lastReported) >= r.dedupWindow {
+			delete(r.dedup, key)
+		}
+	}
+}
+
+// evictOldestDedup removes the least recently reported key, so that the
+// deduplication state stays bounded even if every key is within the window.
+func (r *errorReporting) evictOldestDedup() {
+	var (
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, state := range r.dedup {
+		if oldestKey == "" || state.lastReported.Before(oldest) {
+			oldestKey, oldest = key, state.lastReported
+		}
+	}
+	delete(r.dedup, oldestKey)
+}
+
+// Init implements the Worker interface.
+func (r *errorReporting) Init(logger *zap.Logger) error {
+	r.logger = logger
+
+	return nil
+}
+
+// Run implements the Worker interface. It sends the still queued reports
+// before returning.
+func (r *errorReporting) Run() error {
+	done := make(chan struct{})
+	defer close(done)
+	r.mu.Lock()
+	if r.terminated {
+		// Terminate already drained the queue.
+		r.mu.Unlock()
+		return nil
+	}
+	r.runDone = done
+	r.mu.Unlock()
+
+	for {
+		select {
+		case report := <-r.reports:
+			r.report(report)
+		case <-r.stop:
+			r.drain()
+			return nil
+		}
+	}
+}
+
+// Terminate implements the Worker interface. It waits for Run to send the
+// still queued reports, or sends them itself if Run was never started.
+func (r *errorReporting) Terminate() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	r.mu.Lock()
+	r.terminated = true
+	done := r.runDone
+	r.mu.Unlock()
+
+	if done != nil {
+		<-done
+	} else {
+		r.drain()
+	}
+	return nil
+}
+
+func (r *errorReporting) drain() {
+	for {
+		select {
+		case report := <-r.reports:
+			r.report(report)
+		default:
+			return
+		}
+	}
+}
+
+func (r *errorReporting) report(report ErrorReport) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	if err := r.reporter.Report(ctx, report); err != nil {
+		r.logger.Warn("Could not report error", zap.String("message", report.Message), zap.Error(err))
+	}
+}
+
+// callerStack returns the stack trace of the code that emitted the log entry,
+// skipping the frames of zap and the core itself.
+func callerStack() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	var b strings.Builder
+	inZap, started := false, false
+	for {
+		frame, more := frames.Next()
+		if !started {
+			if strings.HasPrefix(frame.Function, "go.uber.org/zap") {
+				inZap = true
+			} else if inZap {
+				started = true
+			}
+		}
+		if started {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+var _ zapcore.Core = (*errorReportCore)(nil)
+
+// errorReportCore is a zapcore.Core queuing entries to be reported by an
+// errorReporting. Like the logBufferCore, it encodes the fields as JSON.
+type errorReportCore struct {
+	reporting *errorReporting
+	enc       zapcore.Encoder
+	// err is the message of an `error` field added with With.
+	err string
+}
+
+func newErrorReportCore(r *errorReporting) *errorReportCore {
+	// Without keys, the encoder encodes the fields only.
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+	})
+	return &errorReportCore{reporting: r, enc: enc}
+}
+
+// Enabled implements the zapcore.Core interface.
+func (c *errorReportCore) Enabled(level zapcore.Level) bool {
+	return level >= c.reporting.level
+}
+
+// With implements the zapcore.Core interface.
+func (c *errorReportCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &errorReportCore{
+		reporting: c.reporting,
+		enc:       enc,
+		err:       errorMessage(fields, c.err),
+	}
+}
+
+// Check implements the zapcore.Core interface.
+func (c *errorReportCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// Failed reports are logged by the worker itself and must not feed back.
+	if c.Enabled(e.Level) && e.LoggerName != errorReporterWorkerName {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+// Write implements the zapcore.Core interface.
+func (c *errorReportCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	var encoded json.RawMessage
+	if b := bytes.TrimSpace(buf.Bytes()); string(b) != "{}" {
+		encoded = append(json.RawMessage{}, b...)
+	}
+	c.reporting.enqueue(e, errorMessage(fields, c.err), encoded)
+
+	return nil
+}
+
+// Sync implements the zapcore.Core interface.
+func (c *errorReportCore) Sync() error {
+	return nil
+}
+
+// errorMessage returns the message of the last `error` field, as added by
+// zap.Error, or the given fallback if there is none.
+func errorMessage(fields []zapcore.Field, fallback string) string {
+	msg := fallback
+	for _, f := range fields {
+		if f.Key != "error" || f.Type != zapcore.ErrorType {
+			continue
+		}
+		if err, ok := f.Interface.(error); ok {
+			msg = err.Error()
+		}
+	}
+	return msg
+}
+
+var _ ErrorReporter = (*HTTPErrorReporter)(nil)
+
+// HTTPErrorReporter is an ErrorReporter posting each report as JSON to an HTTP
+// endpoint.
+type HTTPErrorReporter struct {
+	URL    string
+	Client *http.Client
+	Header http.Header
+}
+
+// NewHTTPErrorReporter returns an HTTPErrorReporter posting to the given URL
+// using the default HTTP client.
+func NewHTTPErrorReporter(url string) *HTTPErrorReporter {
+	return &HTTPErrorReporter{URL: url, Client: http.DefaultClient, Header: http.Header{}}
+}
+
+// Report implements the ErrorReporter interface.
+func (h *HTTPErrorReporter) Report(ctx context.Context, report ErrorReport) error {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, v := range h.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestErrorReporter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reports []ErrorReport
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report ErrorReport
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		mu.Lock()
+		reports = append(reports, report)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	s, err := New("dummy-service", "v0.0.0", WithErrorReporter(NewHTTPErrorReporter(collector.URL)))
+	require.NoError(t, err)
+
+	s.AddWorker("dummy-worker", &WorkerMock{
+		InitFunc: func(logger *zap.Logger) error {
+			for i := 0; i < 3; i++ {
+				logger.Error("duplicated failure")
+			}
+			logger.Warn("not reported")
+			logger.With(zap.String("task", "sync")).Error("other failure",
+				zap.Error(errors.New("connection refused")),
+				zap.Int("attempt", 2),
+			)
+			return nil
+		},
+		RunFunc:       func() error { return nil },
+		TerminateFunc: func() error { return nil },
+	})
+	// The error reporter worker runs until the service gets shut down.
+	s.Shutdown()
+	s.Run()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, reports, 2)
+	assert.Equal(t, "duplicated failure", reports[0].Message)
+	assert.Equal(t, "dummy-worker", reports[0].LoggerName)
+	assert.Equal(t, "dummy-service", reports[0].Service)
+	assert.Equal(t, "v0.0.0", reports[0].Version)
+	assert.Equal(t, "error", reports[0].Level)
+	assert.Equal(t, 1, reports[0].Count)
+	assert.Contains(t, reports[0].Stack, "TestErrorReporter")
+	assert.NotContains(t, reports[0].Stack, "go.uber.org/zap")
+	assert.Empty(t, reports[0].Error)
+	assert.Empty(t, reports[0].Fields)
+	assert.Equal(t, "other failure", reports[1].Message)
+	assert.Equal(t, "connection refused", reports[1].Error)
+	assert.JSONEq(t, `{"task":"sync","error":"connection refused","attempt":2}`, string(reports[1].Fields))
+}
+
+func TestErrorReportingTerminateWithoutRun(t *testing.T) {
+	var reported []string
+	r := newErrorReporting(errorReporterFunc(func(_ context.Context, report ErrorReport) error {
+		reported = append(reported, report.Message)
+		return nil
+	}), "dummy-service", "v0.0.0")
+	require.NoError(t, r.Init(zap.NewNop()))
+
+	logger := zap.New(newErrorReportCore(r))
+	logger.Error("queued failure")
+
+	require.NoError(t, r.Terminate())
+	assert.Equal(t, []string{"queued failure"}, reported)
+	assert.NoError(t, r.Run(), "returns immediately once terminated")
+}
+
+type errorReporterFunc func(ctx context.Context, report ErrorReport) error
+
+func (f errorReporterFunc) Report(ctx context.Context, report ErrorReport) error {
+	return f(ctx, report)
+}
+
+func TestErrorReportingAdmit(t *testing.T) {
+	r := newErrorReporting(nil, "dummy-service", "v0.0.0",
+		ErrorReportRateLimit(2, time.Minute),
+		ErrorReportDedupWindow(10*time.Second),
+	)
+	now := time.Now()
+	entry := func(msg string, offset time.Duration) zapcore.Entry {
+		return zapcore.Entry{Level: zapcore.ErrorLevel, Message: msg, Time: now.Add(offset)}
+	}
+
+	count, ok := r.admit(entry("a", 0))
+	assert.True(t, ok)
+	assert.Equal(t, 1, count)
+
+	_, ok = r.admit(entry("a", time.Second))
+	assert.False(t, ok, "deduplicated")
+
+	_, ok = r.admit(entry("b", 2*time.Second))
+	assert.True(t, ok)
+
+	_, ok = r.admit(entry("c", 3*time.Second))
+	assert.False(t, ok, "rate limited")
+
+	count, ok = r.admit(entry("a", time.Minute))
+	assert.True(t, ok, "dedup window and rate period passed")
+	assert.Equal(t, 2, count)
+}
+
+func TestErrorReportingDedupBounded(t *testing.T) {
+	r := newErrorReporting(nil, "dummy-service", "v0.0.0",
+		ErrorReportRateLimit(errorReportDedupMaxKeys+1, time.Minute),
+	)
+	now := time.Now()
+	for i := 0; i <= errorReportDedupMaxKeys; i++ {
+		_, ok := r.admit(zapcore.Entry{Message: fmt.Sprint(i), Time: now.Add(time.Duration(i) * time.Millisecond)})
+		require.True(t, ok)
+	}
+
+	assert.Len(t, r.dedup, errorReportDedupMaxKeys)
+	assert.NotContains(t, r.dedup, "0\x00undefined", "oldest key evicted")
+	assert.Contains(t, r.dedup, fmt.Sprint(errorReportDedupMaxKeys)+"\x00undefined")
+}
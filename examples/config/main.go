@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/voi-oss/svc"
+	"go.uber.org/zap"
+)
+
+type config struct {
+	Port     string        `env:"PORT" envDefault:"8080" validate:"required" desc:"Port of the internal HTTP server."`
+	Interval time.Duration `env:"INTERVAL" envDefault:"1s" validate:"min=100ms" desc:"Interval between two greetings."`
+	Greeting string        `env:"GREETING" envDefault:"Hello" desc:"Greeting to log."`
+	APIKey   string        `env:"API_KEY" secret:"true" desc:"Key of the greeting API."`
+}
+
+var _ svc.Worker = (*greeter)(nil)
+
+type greeter struct {
+	cfg    *config
+	logger *zap.Logger
+	stop   chan struct{}
+}
+
+func (g *greeter) Init(logger *zap.Logger) error { g.logger = logger; return nil }
+func (g *greeter) Terminate() error              { close(g.stop); return nil }
+func (g *greeter) Run() error {
+	ticker := time.NewTicker(g.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stop:
+			return nil
+		case <-ticker.C:
+			g.logger.Info(g.cfg.Greeting)
+		}
+	}
+}
+
+func main() {
+	cfg := &config{}
+
+	// `go run ./examples/config config docs [markdown|json]` prints the
+	// configuration reference, see `go run ./examples/config help` for the
+	// other commands. Passing the arguments lets the commands that don't need
+	// the configuration run without a valid one.
+	s, err := svc.New("config-service", "1.0.0",
+		svc.WithCommandArgs(os.Args[1:]),
+		svc.WithConfig(cfg, svc.ConfigFlags(svc.FlagArgs(os.Args[1:]))),
+		// The port is read once the config got loaded.
+		func(s *svc.SVC) error { return svc.WithHTTPServer(cfg.Port)(s) },
+		svc.WithHealthz(),
+		svc.WithConfigHandler(),
+	)
+	svc.MustInit(s, err)
+
+	s.AddWorker("greeter", &greeter{cfg: cfg, stop: make(chan struct{})})
+
+	svc.Main(s)
+}
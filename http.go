@@ -2,14 +2,18 @@ package svc
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+const internalHTTPServerName = "internal-http-server"
+
 var _ Worker = (*httpServer)(nil)
 
 // httpServer defines the internal HTTP Server worker.
@@ -17,6 +21,9 @@ type httpServer struct {
 	logger     *zap.Logger
 	addr       string
 	httpServer *http.Server
+
+	mu       sync.Mutex
+	listener net.Listener
 }
 
 func newHTTPServer(port string, handler http.Handler, logger *log.Logger) *httpServer {
@@ -32,13 +39,33 @@ func newHTTPServer(port string, handler http.Handler, logger *log.Logger) *httpS
 	}
 }
 
-// Init implements the Worker interface.
+// Init implements the Worker interface. It binds the server's address, so
+// that the actual port is known once all workers are initialized.
 func (s *httpServer) Init(logger *zap.Logger) error {
 	s.logger = logger
 
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
 	return nil
 }
 
+// listenAddr returns the address the server is bound to, or an empty string
+// if it is not yet.
+func (s *httpServer) listenAddr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
 // Healthy implements the Healther interface.
 func (s *httpServer) Healthy() error {
 	return nil
@@ -46,8 +73,8 @@ func (s *httpServer) Healthy() error {
 
 // Run implements the Worker interface.
 func (s *httpServer) Run() error {
-	s.logger.Info("Listening and serving HTTP", zap.String("address", s.addr))
-	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+	s.logger.Info("Listening and serving HTTP", zap.String("address", s.listenAddr()))
+	if err := s.httpServer.Serve(s.listener); err != http.ErrServerClosed {
 		s.logger.Error("Failed to serve HTTP", zap.Error(err))
 	}
 	return nil
@@ -55,5 +82,10 @@ func (s *httpServer) Run() error {
 
 // Terminate implements the Worker interface.
 func (s *httpServer) Terminate() error {
-	return s.httpServer.Shutdown(context.Background())
+	err := s.httpServer.Shutdown(context.Background())
+	// The listener is not closed by Shutdown if the server was never run.
+	if closeErr := s.listener.Close(); err == nil && !errors.Is(closeErr, net.ErrClosed) {
+		err = closeErr
+	}
+	return err
 }
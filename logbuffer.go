@@ -0,0 +1,164 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// bufferedEntry is a log entry kept by the logBuffer.
+type bufferedEntry struct {
+	Time       time.Time       `json:"time"`
+	Level      zapcore.Level   `json:"level"`
+	LoggerName string          `json:"logger,omitempty"`
+	Message    string          `json:"message"`
+	Caller     string          `json:"caller,omitempty"`
+	Stack      string          `json:"stacktrace,omitempty"`
+	Fields     json.RawMessage `json:"fields,omitempty"`
+}
+
+// logBuffer is a fixed size ring buffer of the most recent log entries.
+type logBuffer struct {
+	mu      sync.Mutex
+	entries []bufferedEntry
+	next    int
+	full    bool
+}
+
+func newLogBuffer(size int) *logBuffer {
+	return &logBuffer{entries: make([]bufferedEntry, size)}
+}
+
+func (b *logBuffer) add(e bufferedEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = e
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// snapshot returns the buffered entries, oldest first, that are at least of
+// the given level and whose logger is the given one or a child of it.
+func (b *logBuffer) snapshot(level zapcore.Level, loggerName string) []bufferedEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ordered := b.entries[:b.next]
+	if b.full {
+		ordered = append(append([]bufferedEntry{}, b.entries[b.next:]...), b.entries[:b.next]...)
+	}
+
+	entries := []bufferedEntry{}
+	for _, e := range ordered {
+		if e.Level < level || !isLoggerOrChild(e.LoggerName, loggerName) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// isLoggerOrChild returns whether the logger name is the given parent's, e.g.
+// `worker`, or one of its children's, e.g. `worker.http`. Any name is a child
+// of the empty name.
+func isLoggerOrChild(name, parent string) bool {
+	return parent == "" || name == parent || strings.HasPrefix(name, parent+".")
+}
+
+// ServeHTTP serves the buffered entries as JSON. The entries can be filtered
+// with the `level` (minimum level) and `logger` (logger name, including its
+// children) query parameters.
+func (b *logBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	level := zapcore.DebugLevel
+	if l := r.URL.Query().Get("level"); l != "" {
+		if err := level.UnmarshalText([]byte(l)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries := b.snapshot(level, r.URL.Query().Get("logger"))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var _ zapcore.Core = (*logBufferCore)(nil)
+
+// logBufferCore is a zapcore.Core writing entries into a logBuffer. The fields
+// are encoded as JSON when written, so that any field can be served.
+type logBufferCore struct {
+	zapcore.LevelEnabler
+	buffer *logBuffer
+	enc    zapcore.Encoder
+}
+
+func newLogBufferCore(buffer *logBuffer, enab zapcore.LevelEnabler) *logBufferCore {
+	// Without keys, the encoder encodes the fields only.
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+	})
+	return &logBufferCore{LevelEnabler: enab, buffer: buffer, enc: enc}
+}
+
+// With implements the zapcore.Core interface.
+func (c *logBufferCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &logBufferCore{
+		LevelEnabler: c.LevelEnabler,
+		buffer:       c.buffer,
+		enc:          enc,
+	}
+}
+
+// Check implements the zapcore.Core interface.
+func (c *logBufferCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+// Write implements the zapcore.Core interface.
+func (c *logBufferCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	entry := bufferedEntry{
+		Time:       e.Time,
+		Level:      e.Level,
+		LoggerName: e.LoggerName,
+		Message:    e.Message,
+		Stack:      e.Stack,
+	}
+	if encoded := bytes.TrimSpace(buf.Bytes()); string(encoded) != "{}" {
+		entry.Fields = append(json.RawMessage{}, encoded...)
+	}
+	if e.Caller.Defined {
+		entry.Caller = e.Caller.TrimmedPath()
+	}
+	c.buffer.add(entry)
+
+	return nil
+}
+
+// Sync implements the zapcore.Core interface.
+func (c *logBufferCore) Sync() error {
+	return nil
+}
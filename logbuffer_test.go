@@ -0,0 +1,108 @@
+package svc
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRecentLogsHandler(t *testing.T) {
+	s, err := New("dummy-service", "v0.0.0", WithRecentLogsHandler(3, zap.InfoLevel))
+	require.NoError(t, err)
+
+	s.Logger().Debug("not buffered")
+	s.Logger().Info("dropped by ring buffer")
+	s.Logger().Named("worker").Named("http").Info("first", zap.String("key", "value"))
+	s.Logger().Named("workerpool").Warn("second")
+	s.Logger().Named("worker").With(zap.Int("attempt", 2)).Error("third")
+
+	tests := []struct {
+		name             string
+		query            string
+		expectedMessages []string
+	}{
+		{
+			name:             "all entries",
+			expectedMessages: []string{"first", "second", "third"},
+		},
+		{
+			name:             "filtered by level",
+			query:            "?level=warn",
+			expectedMessages: []string{"second", "third"},
+		},
+		{
+			name:             "filtered by logger name",
+			query:            "?logger=worker",
+			expectedMessages: []string{"first", "third"},
+		},
+		{
+			name:             "filtered by level and logger name",
+			query:            "?level=error&logger=worker",
+			expectedMessages: []string{"third"},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/debug/logs"+tc.query, nil)
+			rec := httptest.NewRecorder()
+			s.Router.ServeHTTP(rec, req)
+			require.Equal(t, 200, rec.Code)
+
+			var entries []bufferedEntry
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+
+			var messages []string
+			for _, e := range entries {
+				messages = append(messages, e.Message)
+			}
+			assert.Equal(t, tc.expectedMessages, messages)
+		})
+	}
+
+	t.Run("fields are kept", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/debug/logs?level=error", nil)
+		rec := httptest.NewRecorder()
+		s.Router.ServeHTTP(rec, req)
+
+		var got []bufferedEntry
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		require.Len(t, got, 1)
+		assert.Equal(t, "worker", got[0].LoggerName)
+		assert.JSONEq(t, `{"attempt": 2}`, string(got[0].Fields))
+	})
+
+	t.Run("unencodable fields", func(t *testing.T) {
+		s.Logger().Info("odd", zap.Float64("nan", math.NaN()), zap.Any("chan", make(chan int)))
+
+		req := httptest.NewRequest("GET", "/debug/logs", nil)
+		rec := httptest.NewRecorder()
+		s.Router.ServeHTTP(rec, req)
+		require.Equal(t, 200, rec.Code)
+
+		var got []bufferedEntry
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		require.Len(t, got, 3)
+		assert.Equal(t, "odd", got[2].Message)
+		assert.Contains(t, string(got[2].Fields), `"nan":"NaN"`)
+		assert.Contains(t, string(got[2].Fields), `"chanError"`)
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/debug/logs?level=loud", nil)
+		rec := httptest.NewRecorder()
+		s.Router.ServeHTTP(rec, req)
+		assert.Equal(t, 400, rec.Code)
+	})
+}
+
+func TestRecentLogsHandlerInvalidSize(t *testing.T) {
+	_, err := New("dummy-service", "v0.0.0", WithRecentLogsHandler(0, zap.InfoLevel))
+	require.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package svc
+
+import (
+	"context"
+	"os"
+
+	"github.com/blendle/zapdriver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the given request ID.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// logCorrelation formats the trace context and request ID as log fields in
+// the way of the logger's encoder.
+type logCorrelation func(s *SVC, span trace.SpanContext, requestID string) []zap.Field
+
+func defaultLogCorrelation(_ *SVC, span trace.SpanContext, requestID string) []zap.Field {
+	var fields []zap.Field
+	if span.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", span.TraceID().String()),
+			zap.String("span_id", span.SpanID().String()),
+		)
+	}
+	if requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	return fields
+}
+
+// stackdriverLogCorrelation falls back to the default fields without a GCP
+// project, as Cloud Logging cannot link a trace of an unknown project.
+func stackdriverLogCorrelation(s *SVC, span trace.SpanContext, requestID string) []zap.Field {
+	if s.gcpProject == "" {
+		return defaultLogCorrelation(s, span, requestID)
+	}
+
+	var fields []zap.Field
+	if span.IsValid() {
+		fields = append(fields, zapdriver.TraceContext(
+			span.TraceID().String(), span.SpanID().String(), span.IsSampled(), s.gcpProject,
+		)...)
+	}
+	if requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	return fields
+}
+
+func ecsLogCorrelation(_ *SVC, span trace.SpanContext, requestID string) []zap.Field {
+	var fields []zap.Field
+	if span.IsValid() {
+		fields = append(fields,
+			zap.String("trace.id", span.TraceID().String()),
+			zap.String("span.id", span.SpanID().String()),
+		)
+	}
+	if requestID != "" {
+		fields = append(fields, zap.String("http.request.id", requestID))
+	}
+	return fields
+}
+
+func defaultGCPProject() string {
+	return os.Getenv("GOOGLE_CLOUD_PROJECT")
+}
+
+// ContextFields returns the log fields correlating a log entry with the trace
+// and span of the OpenTelemetry span carried by ctx, and the request ID set by
+// ContextWithRequestID. The fields are named according to the logger's format,
+// e.g. `logging.googleapis.com/trace` for WithStackdriverLogger.
+func (s *SVC) ContextFields(ctx context.Context) []zap.Field {
+	requestID, _ := RequestIDFromContext(ctx)
+	return s.logCorrelation(s, trace.SpanContextFromContext(ctx), requestID)
+}
+
+// LoggerFromContext returns the service's logger with the fields returned by
+// ContextFields. Workers can add these fields to their own logger instead.
+func (s *SVC) LoggerFromContext(ctx context.Context) *zap.Logger {
+	return s.logger.With(s.ContextFields(ctx)...)
+}
@@ -0,0 +1,99 @@
+package svc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContextFields(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929b0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = ContextWithRequestID(ctx, "req-1")
+
+	fieldMap := func(fields []zap.Field) map[string]interface{} {
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		return enc.Fields
+	}
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		fields map[string]interface{}
+	}{
+		{
+			name: "default",
+			fields: map[string]interface{}{
+				"trace_id":   "4bf92f3577b34da6a3ce929b0e0e4736",
+				"span_id":    "00f067aa0ba902b7",
+				"request_id": "req-1",
+			},
+		},
+		{
+			name: "stackdriver",
+			opts: []Option{WithStackdriverLogger(zapcore.InfoLevel), WithStackdriverProject("my-project")},
+			fields: map[string]interface{}{
+				"logging.googleapis.com/trace":         "projects/my-project/traces/4bf92f3577b34da6a3ce929b0e0e4736",
+				"logging.googleapis.com/spanId":        "00f067aa0ba902b7",
+				"logging.googleapis.com/trace_sampled": true,
+				"request_id":                           "req-1",
+			},
+		},
+		{
+			name: "stackdriver without project",
+			opts: []Option{WithStackdriverLogger(zapcore.InfoLevel)},
+			fields: map[string]interface{}{
+				"trace_id":   "4bf92f3577b34da6a3ce929b0e0e4736",
+				"span_id":    "00f067aa0ba902b7",
+				"request_id": "req-1",
+			},
+		},
+		{
+			name: "ecs",
+			opts: []Option{WithECSLogger(zapcore.InfoLevel)},
+			fields: map[string]interface{}{
+				"trace.id":        "4bf92f3577b34da6a3ce929b0e0e4736",
+				"span.id":         "00f067aa0ba902b7",
+				"http.request.id": "req-1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New("dummy-service", "v0.0.0", tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.fields, fieldMap(s.ContextFields(ctx)))
+			assert.Empty(t, s.ContextFields(context.Background()))
+		})
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	s, err := New("dummy-service", "v0.0.0", WithLogger(zap.New(core), zap.NewAtomicLevel()))
+	require.NoError(t, err)
+
+	s.LoggerFromContext(ContextWithRequestID(context.Background(), "req-1")).Info("handled")
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "req-1", logs.All()[0].ContextMap()["request_id"])
+}
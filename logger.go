@@ -1,12 +1,18 @@
 package svc
 
 import (
+	"log"
+	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/blendle/zapdriver"
+	zaplogfmt "github.com/jsternberg/zap-logfmt"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.elastic.co/ecszap"
 	"go.uber.org/zap"
+	"go.uber.org/zap/exp/zapslog"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -15,10 +21,11 @@ func (s *SVC) newLogger(level zapcore.Level, encoder zapcore.Encoder) (*zap.Logg
 	atom.SetLevel(level)
 
 	s.zapOpts = append(s.zapOpts, zap.ErrorOutput(zapcore.Lock(os.Stderr)), zap.AddCaller())
+	s.logCorrelation = defaultLogCorrelation
 
 	logger := zap.New(zapcore.NewSamplerWithOptions(zapcore.NewCore(
 		encoder,
-		zapcore.Lock(os.Stdout),
+		s.logSink,
 		atom,
 	), time.Second, 10, 10),
 		s.zapOpts...,
@@ -27,9 +34,48 @@ func (s *SVC) newLogger(level zapcore.Level, encoder zapcore.Encoder) (*zap.Logg
 	return logger, atom
 }
 
+var _ zapcore.WriteSyncer = (*logSink)(nil)
+
+// logSink is the output of the built-in loggers, stdout by default. Execute
+// switches it to stderr for the commands printing their result to stdout.
+type logSink struct {
+	mu sync.Mutex
+	w  zapcore.WriteSyncer
+}
+
+// Write implements the zapcore.WriteSyncer interface.
+func (l *logSink) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// Sync implements the zapcore.WriteSyncer interface.
+func (l *logSink) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Sync()
+}
+
+func (l *logSink) set(w zapcore.WriteSyncer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w = w
+}
+
 // WithZapMetrics will add a hook to the zap logger and emit metrics to prometheus
-// based on log level and log name.
+// based on log level and log name. The metrics are registered in the service's
+// internal registry.
 func WithZapMetrics() Option {
+	return func(s *SVC) error {
+		return WithZapMetricsRegisterer(s.internalRegister)(s)
+	}
+}
+
+// WithZapMetricsRegisterer will add a hook to the zap logger and emit metrics
+// to prometheus based on log level and log name. The metrics are registered in
+// the given registerer.
+func WithZapMetricsRegisterer(registerer prometheus.Registerer) Option {
 	return func(s *SVC) error {
 		requestCount := prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -38,20 +84,18 @@ func WithZapMetrics() Option {
 			},
 			[]string{"level", "logger_name"},
 		)
-		if err := prometheus.Register(requestCount); err != nil {
+		if err := registerer.Register(requestCount); err != nil {
 			return err
 		}
 
-		s.zapOpts = append(s.zapOpts,
-			zap.Hooks(func(e zapcore.Entry) error {
-				counter, err := requestCount.GetMetricWithLabelValues(e.Level.String(), e.LoggerName)
-				if err != nil {
-					return err
-				}
-				counter.Inc()
-				return nil
-			}))
-		return nil
+		return s.wrapLogger(zap.Hooks(func(e zapcore.Entry) error {
+			counter, err := requestCount.GetMetricWithLabelValues(e.Level.String(), e.LoggerName)
+			if err != nil {
+				return err
+			}
+			counter.Inc()
+			return nil
+		}))
 	}
 }
 
@@ -116,24 +160,112 @@ func WithStackdriverLogger(level zapcore.Level, opts ...zap.Option) Option {
 			zapcore.NewJSONEncoder(zapdriver.NewProductionEncoderConfig()),
 		)
 		logger = logger.With(zapdriver.ServiceContext(s.Name), zapdriver.Label("version", s.Version))
+		s.logCorrelation = stackdriverLogCorrelation
 		return assignLogger(s, logger, atom)
 	}
 }
 
+// WithLogfmtLogger is an option that uses a zap Logger with configurations
+// set meant to be used for production and emits entries in the logfmt format.
+func WithLogfmtLogger(level zapcore.Level, opts ...zap.Option) Option {
+	return func(s *SVC) error {
+		config := zap.NewProductionEncoderConfig()
+		config.EncodeTime = zapcore.RFC3339TimeEncoder
+		s.zapOpts = append(s.zapOpts, opts...)
+
+		logger, atom := s.newLogger(
+			level,
+			zaplogfmt.NewEncoder(config),
+		)
+		logger = logger.With(zap.String("app", s.Name), zap.String("version", s.Version))
+		return assignLogger(s, logger, atom)
+	}
+}
+
+// WithECSLogger is an option that uses a zap Logger with configurations set
+// meant to be used for production and is compliant with the Elastic Common
+// Schema (ECS) format.
+func WithECSLogger(level zapcore.Level, opts ...zap.Option) Option {
+	return func(s *SVC) error {
+		s.zapOpts = append(s.zapOpts, opts...)
+		logger, atom := s.newLogger(
+			level,
+			zapcore.NewJSONEncoder(ecszap.NewDefaultEncoderConfig().ToZapCoreEncoderConfig()),
+		)
+		logger = logger.WithOptions(ecszap.WrapCoreOption()).
+			With(zap.String("service.name", s.Name), zap.String("service.version", s.Version))
+		s.logCorrelation = ecsLogCorrelation
+		return assignLogger(s, logger, atom)
+	}
+}
+
+// WithSlogDefault is an option that sets the service's logger as the default
+// log/slog Logger, the same way the standard log package gets redirected.
+func WithSlogDefault() Option {
+	return func(s *SVC) error {
+		s.slogDefault = true
+		return assignLogger(s, s.baseLogger, s.atom)
+	}
+}
+
+// SlogLogger returns a log/slog Logger backed by the service's logger. It
+// shares the zap core, thus level, hooks, and output, with Logger.
+func (s *SVC) SlogLogger() *slog.Logger {
+	return newSlogLogger(s.logger)
+}
+
+func newSlogLogger(logger *zap.Logger) *slog.Logger {
+	return slog.New(zapslog.NewHandler(logger.Core(), zapslog.WithCaller(true)))
+}
+
+// wrapLogger applies the given options to the service's logger. Unlike options
+// passed to a logger option, they are kept applied when the logger gets
+// replaced by a subsequent logger option.
+func (s *SVC) wrapLogger(opts ...zap.Option) error {
+	s.loggerWrappers = append(s.loggerWrappers, opts...)
+	return assignLogger(s, s.baseLogger, s.atom)
+}
+
 func assignLogger(s *SVC, logger *zap.Logger, atom zap.AtomicLevel) error {
+	if s.loggerRedirectUndo != nil {
+		s.loggerRedirectUndo()
+	}
+
+	baseLogger := logger
+	logger = logger.WithOptions(s.loggerWrappers...)
+
 	stdLogger, err := zap.NewStdLogAt(logger, zapcore.ErrorLevel)
 	if err != nil {
 		return err
 	}
-	undo, err := zap.RedirectStdLogAt(logger, zapcore.ErrorLevel)
+
+	// Setting the default slog Logger also redirects the standard log package,
+	// so it has to happen before redirecting that one to zap.
+	undoSlog := func() {}
+	if s.slogDefault {
+		prev, prevOutput, prevFlags := slog.Default(), log.Writer(), log.Flags()
+		slog.SetDefault(newSlogLogger(logger))
+		undoSlog = func() {
+			slog.SetDefault(prev)
+			log.SetOutput(prevOutput)
+			log.SetFlags(prevFlags)
+		}
+	}
+
+	undoStd, err := zap.RedirectStdLogAt(logger, zapcore.ErrorLevel)
 	if err != nil {
+		undoSlog()
 		return err
 	}
 
 	s.logger = logger
+	s.baseLogger = baseLogger
 	s.stdLogger = stdLogger
 	s.atom = atom
-	s.loggerRedirectUndo = undo
+	s.loggerRedirectUndo = func() {
+		undoStd()
+		undoSlog()
+	}
 
 	return nil
 }
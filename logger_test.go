@@ -1,11 +1,16 @@
 package svc
 
 import (
+	"log"
+	"log/slog"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestNewLogger(t *testing.T) {
@@ -49,6 +54,22 @@ func TestNewLogger(t *testing.T) {
 				return core
 			}), zap.AddCaller()),
 		},
+		{
+			name:          "logfmt logger",
+			serviceOption: WithLogfmtLogger(zap.InfoLevel),
+		},
+		{
+			name:          "logfmt logger with options",
+			serviceOption: WithLogfmtLogger(zap.DebugLevel, zap.AddCaller()),
+		},
+		{
+			name:          "ecs logger",
+			serviceOption: WithECSLogger(zap.InfoLevel),
+		},
+		{
+			name:          "ecs logger with options",
+			serviceOption: WithECSLogger(zap.DebugLevel, zap.AddCaller()),
+		},
 	}
 
 	for _, tt := range tests {
@@ -59,3 +80,98 @@ func TestNewLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestSlogLogger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	s, err := New("dummy-name", "dummy-version", WithLogger(zap.New(core), zap.NewAtomicLevel()))
+	require.NoError(t, err)
+
+	logger := s.SlogLogger()
+	logger.Debug("filtered")
+	logger.Info("hello", "key", "value")
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	require.Equal(t, "hello", entry.Message)
+	require.Equal(t, map[string]interface{}{"key": "value"}, entry.ContextMap())
+}
+
+func TestWithSlogDefault(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	s, err := New("dummy-name", "dummy-version",
+		WithSlogDefault(),
+		WithLogger(zap.New(core), zap.NewAtomicLevel()),
+	)
+	require.NoError(t, err)
+
+	slog.Info("via slog")
+	log.Print("via log")
+	s.loggerRedirectUndo()
+	slog.Info("after undo")
+
+	require.Equal(t, 2, logs.Len())
+	require.Equal(t, "via slog", logs.All()[0].Message)
+	require.Equal(t, zap.InfoLevel, logs.All()[0].Level)
+	require.Equal(t, "via log", logs.All()[1].Message)
+	require.Equal(t, zap.ErrorLevel, logs.All()[1].Level)
+}
+
+func TestWithZapMetrics(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "without logger option",
+			opts: []Option{WithZapMetrics()},
+		},
+		{
+			name: "before logger option",
+			opts: []Option{WithZapMetrics(), WithProductionLogger()},
+		},
+		{
+			name: "after logger option",
+			opts: []Option{WithProductionLogger(), WithZapMetrics()},
+		},
+		{
+			name: "with custom logger",
+			opts: []Option{WithZapMetrics(), WithLogger(zaptest.NewLogger(t), zap.NewAtomicLevel())},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New("dummy-name", "dummy-version", tc.opts...)
+			require.NoError(t, err)
+
+			s.Logger().Named("test").Warn("counted")
+
+			families, err := s.internalRegister.Gather()
+			require.NoError(t, err)
+			require.Len(t, families, 1)
+			require.Equal(t, "logger_emitted_entries", families[0].GetName())
+
+			var count float64
+			for _, m := range families[0].GetMetric() {
+				labels := map[string]string{}
+				for _, l := range m.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+				if labels["level"] == "warn" && labels["logger_name"] == "test" {
+					count += m.GetCounter().GetValue()
+				}
+			}
+			require.Equal(t, float64(1), count)
+		})
+	}
+}
+
+func TestWithZapMetricsRegisterer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	_, err := New("dummy-name", "dummy-version", WithZapMetricsRegisterer(registry))
+	require.NoError(t, err)
+
+	_, err = New("dummy-name", "dummy-version", WithZapMetricsRegisterer(registry))
+	require.Error(t, err, "registering twice in the same registry must fail")
+}
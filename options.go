@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"os"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Option defines SVC's option type.
@@ -33,6 +35,97 @@ func WithTerminationGracePeriod(d time.Duration) Option {
 	}
 }
 
+// WithClock is an option that replaces the clock timing the service's
+// lifecycle, e.g. the termination wait and grace periods, with the given one.
+func WithClock(clock Clock) Option {
+	return func(s *SVC) error {
+		s.clock = clock
+
+		return nil
+	}
+}
+
+// WithConfig is an option that loads the configuration into the given struct
+// pointer during New and makes it available via Config. See LoadConfig for the
+// config options; ConfigServicePrefix reads environment variables prefixed by
+// the service's name. With WithCommandArgs, the configuration is neither
+// loaded nor validated for the commands that don't need it, see Execute.
+func WithConfig(config interface{}, opts ...ConfigOption) Option {
+	return func(s *SVC) error {
+		loader := newConfigLoader(opts...)
+		if loader.prefixFromName {
+			loader.prefix = envPrefix(s.Name)
+			loader.prefixFromName = false
+		}
+		s.config = &serviceConfig{value: config, loaded: &loadedConfig{prefix: loader.prefix}, loader: loader}
+
+		load, strict := configLoading(s.commandArgs)
+		if !load {
+			return nil
+		}
+		loaded, err := loader.load(config)
+		if err != nil && (strict || loaded == nil) {
+			return fmt.Errorf("could not load config of service %s: %w", s.Name, err)
+		}
+		s.config.loaded = loaded
+		s.config.loadErr = err
+
+		return nil
+	}
+}
+
+// WithConfigHandler is an option that logs the configuration loaded by
+// WithConfig once at startup and sets up an HTTP route to read it. Values of
+// fields tagged with `secret:"true"` or read from a secret file are redacted.
+// This option must be passed after WithConfig.
+func WithConfigHandler() Option {
+	return func(s *SVC) error {
+		if s.config == nil {
+			return fmt.Errorf("config handler requires the WithConfig option")
+		}
+
+		s.logConfigOnRun = true
+		s.Router.HandleFunc("/debug/config", s.serveConfig)
+
+		return nil
+	}
+}
+
+// WithConfigReload is an option that reloads the configuration on SIGHUP and,
+// if the interval is positive, when one of the files it was read from
+// changes. Workers implementing the Reloader interface get notified of the
+// new configuration; the struct passed to WithConfig keeps the initial one.
+// Invalid configurations are rejected and logged. This option must be passed
+// after WithConfig.
+func WithConfigReload(interval time.Duration) Option {
+	return func(s *SVC) error {
+		if s.config == nil {
+			return fmt.Errorf("config reload requires the WithConfig option")
+		}
+
+		s.reloadOnSIGHUP = true
+		s.AddWorker(configWatcherWorkerName, newConfigWatcher(s, interval))
+
+		return nil
+	}
+}
+
+// WithCommandArgs is an option that sets the command-line arguments whose
+// command gets run by Main instead of the process' ones. It lets the options
+// passed after it skip what the command doesn't need, so that e.g. `config
+// docs` works without a valid configuration; pass it first, e.g. with
+// os.Args[1:]. For the commands other than run, the built-in loggers write to
+// stderr from then on.
+func WithCommandArgs(args []string) Option {
+	return func(s *SVC) error {
+		s.commandArgs = args
+		if command, _ := parseCommand(args); command != CommandRun {
+			s.logSink.set(zapcore.Lock(os.Stderr))
+		}
+		return nil
+	}
+}
+
 // WithRouter is an option that replaces the HTTP router with the given http
 // router.
 func WithRouter(router *http.ServeMux) Option {
@@ -53,12 +146,84 @@ func WithLogLevelHandlers() Option {
 	}
 }
 
+// WithRecentLogsHandler is an option that keeps the most recent log entries of
+// at least the given level in memory and sets up an HTTP route to read them.
+func WithRecentLogsHandler(size int, level zapcore.Level) Option {
+	return func(s *SVC) error {
+		if size <= 0 {
+			return fmt.Errorf("recent logs size must be positive, got %d", size)
+		}
+
+		buffer := newLogBuffer(size)
+		err := s.wrapLogger(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, newLogBufferCore(buffer, level))
+		}))
+		if err != nil {
+			return err
+		}
+		s.Router.Handle("/debug/logs", buffer)
+
+		return nil
+	}
+}
+
+// WithStackdriverProject is an option that sets the GCP project the trace IDs
+// logged by WithStackdriverLogger refer to. Defaults to the
+// GOOGLE_CLOUD_PROJECT environment variable. Without a project, the trace and
+// span IDs are logged as `trace_id` and `span_id`.
+func WithStackdriverProject(projectID string) Option {
+	return func(s *SVC) error {
+		s.gcpProject = projectID
+
+		return nil
+	}
+}
+
+// WithErrorReporter is an option that forwards error log entries to the given
+// ErrorReporter, deduplicated by message and caller and rate limited. Reports
+// are sent asynchronously by an added worker.
+func WithErrorReporter(reporter ErrorReporter, opts ...ErrorReporterOption) Option {
+	return func(s *SVC) error {
+		r := newErrorReporting(reporter, s.Name, s.Version, opts...)
+		err := s.wrapLogger(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, newErrorReportCore(r))
+		}))
+		if err != nil {
+			return err
+		}
+		s.AddWorker(errorReporterWorkerName, r)
+
+		return nil
+	}
+}
+
 // WithHTTPServer is an option that adds an internal HTTP server exposing
 // observability routes.
 func WithHTTPServer(port string) Option {
 	return func(s *SVC) error {
-		httpServer := newHTTPServer(port, s.Router, s.stdLogger)
-		s.AddWorker("internal-http-server", httpServer)
+		httpServer := newHTTPServer(port, s.traceHandler(s.Router), s.stdLogger)
+		s.AddWorker(internalHTTPServerName, httpServer)
+
+		return nil
+	}
+}
+
+// WithTracing is an option that sets up an OpenTelemetry tracer provider for
+// the service and registers it, along with W3C trace context and baggage
+// propagation, globally until the service shuts down. Requests to the internal
+// HTTP server and the initialization of each worker are traced. Pending spans
+// are flushed after the workers got terminated.
+func WithTracing(opts ...TracingOption) Option {
+	return func(s *SVC) error {
+		t, err := newTracing(s.Name, s.Version, opts...)
+		if err != nil {
+			return err
+		}
+		s.tracing = t
+		s.tracer = t.provider.Tracer(tracerName)
+		if w, ok := s.workers[internalHTTPServerName].(*httpServer); ok {
+			w.httpServer.Handler = s.traceHandler(w.httpServer.Handler)
+		}
 
 		return nil
 	}
@@ -84,6 +249,22 @@ func WithMetrics() Option {
 	}
 }
 
+// WithPrometheusRegistry is an option that gathers the metrics of the given
+// registry instead of the default Prometheus registry, e.g. to isolate the
+// metrics of services in tests.
+func WithPrometheusRegistry(registry *prometheus.Registry) Option {
+	return func(s *SVC) error {
+		for i, g := range s.gatherers {
+			if g == prometheus.DefaultGatherer {
+				s.gatherers[i] = registry
+			}
+		}
+		s.promHander = nil
+
+		return nil
+	}
+}
+
 // WithMetricsHandler is an option that exposes Prometheus metrics for a
 // Prometheus scraper.
 func WithMetricsHandler() Option {
@@ -97,6 +278,23 @@ func WithMetricsHandler() Option {
 	}
 }
 
+// WithOTLPMetrics is an option that adds a worker periodically pushing the
+// metrics of the service's gatherers, i.e. those exposed by WithMetrics, in
+// the OTLP/HTTP protobuf format to the given endpoint of an OpenTelemetry
+// collector, e.g. `http://otel-collector:4318/v1/metrics`. The metrics are
+// exported a last time when the service shuts down.
+func WithOTLPMetrics(endpoint string, opts ...OTLPMetricsOption) Option {
+	return func(s *SVC) error {
+		e, err := newOTLPMetricsExporter(s, endpoint, opts...)
+		if err != nil {
+			return err
+		}
+		s.AddWorker(otlpMetricsWorkerName, e)
+
+		return nil
+	}
+}
+
 // WithPProfHandlers is an option that exposes Go's Performance Profiler via
 // HTTP routes.
 func WithPProfHandlers() Option {
@@ -125,14 +323,12 @@ func WithHealthz() Option {
 	return func(s *SVC) error {
 		// Register live probe handler
 		s.Router.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
-			var errs []error
-			for n, w := range s.workers {
-				if hw, ok := w.(Aliver); ok {
-					if err := hw.Alive(); err != nil {
-						errs = append(errs, fmt.Errorf("worker %s: %s", n, err))
-					}
+			errs := s.workerErrors(func(w Worker) error {
+				if aw, ok := w.(Aliver); ok {
+					return aw.Alive()
 				}
-			}
+				return nil
+			})
 			if len(errs) == 0 {
 				w.Header().Set("Content-Type", "application/json")
 				_, _ = w.Write([]byte(`{"status": "Still Alive!"}`))
@@ -152,14 +348,12 @@ func WithHealthz() Option {
 
 		// Register ready probe handler
 		s.Router.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-			var errs []error
-			for n, w := range s.workers {
+			errs := s.workerErrors(func(w Worker) error {
 				if hw, ok := w.(Healther); ok {
-					if err := hw.Healthy(); err != nil {
-						errs = append(errs, fmt.Errorf("worker %s: %s", n, err))
-					}
+					return hw.Healthy()
 				}
-			}
+				return nil
+			})
 			if len(errs) > 0 {
 				s.logger.Warn("Ready check failed", zap.Errors("errors", errs))
 				b, err := json.Marshal(map[string]interface{}{"errors": errs})
@@ -0,0 +1,345 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultOTLPMetricsInterval = time.Minute
+	defaultOTLPMetricsTimeout  = 10 * time.Second
+
+	otlpMetricsWorkerName = "otlp-metrics-exporter"
+)
+
+// OTLPMetricsOption defines the option type of WithOTLPMetrics.
+type OTLPMetricsOption func(*otlpMetricsExporter)
+
+// OTLPMetricsInterval sets the interval between two exports. Defaults to one
+// minute.
+func OTLPMetricsInterval(d time.Duration) OTLPMetricsOption {
+	return func(e *otlpMetricsExporter) {
+		e.interval = d
+	}
+}
+
+// OTLPMetricsTimeout sets the timeout of a single export. Defaults to 10s.
+func OTLPMetricsTimeout(d time.Duration) OTLPMetricsOption {
+	return func(e *otlpMetricsExporter) {
+		e.timeout = d
+	}
+}
+
+// OTLPMetricsHeader sets a header sent with each export, e.g. for
+// authentication.
+func OTLPMetricsHeader(key, value string) OTLPMetricsOption {
+	return func(e *otlpMetricsExporter) {
+		e.header.Set(key, value)
+	}
+}
+
+// OTLPMetricsClient sets the HTTP client used to export. Defaults to
+// http.DefaultClient.
+func OTLPMetricsClient(client *http.Client) OTLPMetricsOption {
+	return func(e *otlpMetricsExporter) {
+		e.client = client
+	}
+}
+
+// OTLPMetricsResourceAttributes adds attributes to the exported resource,
+// besides the service's name and version.
+func OTLPMetricsResourceAttributes(attrs ...attribute.KeyValue) OTLPMetricsOption {
+	return func(e *otlpMetricsExporter) {
+		e.attributes = append(e.attributes, attrs...)
+	}
+}
+
+var _ Worker = (*otlpMetricsExporter)(nil)
+
+// newOTLPMetricsExporter returns an exporter pushing the metrics of the given
+// service's gatherers to the endpoint.
+func newOTLPMetricsExporter(s *SVC, endpoint string, opts ...OTLPMetricsOption) (*otlpMetricsExporter, error) {
+	e := &otlpMetricsExporter{
+		endpoint: endpoint,
+		gather:   func() ([]*dto.MetricFamily, error) { return s.gatherers.Gather() },
+		interval: defaultOTLPMetricsInterval,
+		timeout:  defaultOTLPMetricsTimeout,
+		client:   http.DefaultClient,
+		header:   http.Header{},
+		attributes: []attribute.KeyValue{
+			semconv.ServiceName(s.Name),
+			semconv.ServiceVersion(s.Version),
+		},
+		stop: make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	if e.interval <= 0 {
+		return nil, fmt.Errorf("invalid OTLP metrics interval %s", e.interval)
+	}
+	return e, nil
+}
+
+// otlpMetricsExporter is the worker pushing gathered Prometheus metrics to an
+// OTLP/HTTP endpoint.
+type otlpMetricsExporter struct {
+	endpoint   string
+	gather     prometheus.GathererFunc
+	interval   time.Duration
+	timeout    time.Duration
+	client     *http.Client
+	header     http.Header
+	attributes []attribute.KeyValue
+
+	logger    *zap.Logger
+	startTime time.Time
+
+	mu       sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+	runDone  chan struct{}
+}
+
+// Init implements the Worker interface.
+func (e *otlpMetricsExporter) Init(logger *zap.Logger) error {
+	e.logger = logger
+	e.startTime = time.Now()
+
+	return nil
+}
+
+// Run implements the Worker interface.
+func (e *otlpMetricsExporter) Run() error {
+	done := make(chan struct{})
+	defer close(done)
+	e.mu.Lock()
+	e.runDone = done
+	e.mu.Unlock()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.exportLogged()
+		case <-e.stop:
+			return nil
+		}
+	}
+}
+
+// Terminate implements the Worker interface. It exports the metrics a last
+// time before returning.
+func (e *otlpMetricsExporter) Terminate() error {
+	e.stopOnce.Do(func() { close(e.stop) })
+	e.mu.Lock()
+	done := e.runDone
+	e.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+
+	return e.export()
+}
+
+func (e *otlpMetricsExporter) exportLogged() {
+	if err := e.export(); err != nil {
+		e.logger.Warn("Could not export metrics", zap.String("endpoint", e.endpoint), zap.Error(err))
+	}
+}
+
+func (e *otlpMetricsExporter) export() error {
+	// Gatherers return the metrics they could gather along with the error.
+	families, err := e.gather()
+	if err != nil {
+		e.logger.Warn("Could not gather all metrics", zap.Error(err))
+	}
+
+	b, err := proto.Marshal(e.request(families, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, v := range e.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// request converts gathered metric families into an OTLP export request.
+// Counters become cumulative monotonic sums; gauges and untyped metrics become
+// gauges.
+func (e *otlpMetricsExporter) request(families []*dto.MetricFamily, now time.Time) *colmetricspb.ExportMetricsServiceRequest {
+	start, ts := uint64(e.startTime.UnixNano()), uint64(now.UnixNano())
+
+	metrics := make([]*metricspb.Metric, 0, len(families))
+	for _, family := range families {
+		metric := &metricspb.Metric{Name: family.GetName(), Description: family.GetHelp()}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			sum := &metricspb.Sum{
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+				IsMonotonic:            true,
+			}
+			for _, m := range family.GetMetric() {
+				sum.DataPoints = append(sum.DataPoints, numberDataPoint(m, start, ts, m.GetCounter().GetValue()))
+			}
+			metric.Data = &metricspb.Metric_Sum{Sum: sum}
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			gauge := &metricspb.Gauge{}
+			for _, m := range family.GetMetric() {
+				value := m.GetGauge().GetValue()
+				if family.GetType() == dto.MetricType_UNTYPED {
+					value = m.GetUntyped().GetValue()
+				}
+				gauge.DataPoints = append(gauge.DataPoints, numberDataPoint(m, 0, ts, value))
+			}
+			metric.Data = &metricspb.Metric_Gauge{Gauge: gauge}
+		case dto.MetricType_HISTOGRAM:
+			histogram := &metricspb.Histogram{
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			}
+			for _, m := range family.GetMetric() {
+				histogram.DataPoints = append(histogram.DataPoints, histogramDataPoint(m, start, ts))
+			}
+			metric.Data = &metricspb.Metric_Histogram{Histogram: histogram}
+		case dto.MetricType_SUMMARY:
+			summary := &metricspb.Summary{}
+			for _, m := range family.GetMetric() {
+				summary.DataPoints = append(summary.DataPoints, summaryDataPoint(m, start, ts))
+			}
+			metric.Data = &metricspb.Metric_Summary{Summary: summary}
+		default:
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: &resourcepb.Resource{Attributes: otlpAttributes(e.attributes)},
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Scope:   &commonpb.InstrumentationScope{Name: tracerName},
+				Metrics: metrics,
+			}},
+		}},
+	}
+}
+
+func numberDataPoint(m *dto.Metric, start, ts uint64, value float64) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		Attributes:        labelAttributes(m.GetLabel()),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Value:             &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+// histogramDataPoint converts the cumulative buckets of a Prometheus histogram
+// into the per-bucket counts of OTLP, with an implicit +Inf bucket.
+func histogramDataPoint(m *dto.Metric, start, ts uint64) *metricspb.HistogramDataPoint {
+	h := m.GetHistogram()
+	sum := h.GetSampleSum()
+	dp := &metricspb.HistogramDataPoint{
+		Attributes:        labelAttributes(m.GetLabel()),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Count:             h.GetSampleCount(),
+		Sum:               &sum,
+	}
+	var previous uint64
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), +1) {
+			break
+		}
+		dp.ExplicitBounds = append(dp.ExplicitBounds, b.GetUpperBound())
+		dp.BucketCounts = append(dp.BucketCounts, b.GetCumulativeCount()-previous)
+		previous = b.GetCumulativeCount()
+	}
+	dp.BucketCounts = append(dp.BucketCounts, h.GetSampleCount()-previous)
+	return dp
+}
+
+func summaryDataPoint(m *dto.Metric, start, ts uint64) *metricspb.SummaryDataPoint {
+	s := m.GetSummary()
+	dp := &metricspb.SummaryDataPoint{
+		Attributes:        labelAttributes(m.GetLabel()),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Count:             s.GetSampleCount(),
+		Sum:               s.GetSampleSum(),
+	}
+	for _, q := range s.GetQuantile() {
+		dp.QuantileValues = append(dp.QuantileValues, &metricspb.SummaryDataPoint_ValueAtQuantile{
+			Quantile: q.GetQuantile(),
+			Value:    q.GetValue(),
+		})
+	}
+	return dp
+}
+
+func labelAttributes(labels []*dto.LabelPair) []*commonpb.KeyValue {
+	attrs := make([]*commonpb.KeyValue, 0, len(labels))
+	for _, l := range labels {
+		attrs = append(attrs, &commonpb.KeyValue{
+			Key:   l.GetName(),
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: l.GetValue()}},
+		})
+	}
+	return attrs
+}
+
+func otlpAttributes(kvs []attribute.KeyValue) []*commonpb.KeyValue {
+	attrs := make([]*commonpb.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		value := &commonpb.AnyValue{}
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			value.Value = &commonpb.AnyValue_BoolValue{BoolValue: kv.Value.AsBool()}
+		case attribute.INT64:
+			value.Value = &commonpb.AnyValue_IntValue{IntValue: kv.Value.AsInt64()}
+		case attribute.FLOAT64:
+			value.Value = &commonpb.AnyValue_DoubleValue{DoubleValue: kv.Value.AsFloat64()}
+		default:
+			value.Value = &commonpb.AnyValue_StringValue{StringValue: kv.Value.Emit()}
+		}
+		attrs = append(attrs, &commonpb.KeyValue{Key: string(kv.Key), Value: value})
+	}
+	return attrs
+}
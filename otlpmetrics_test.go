@@ -0,0 +1,78 @@
+package svc
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWithOTLPMetrics(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []*colmetricspb.ExportMetricsServiceRequest
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req := &colmetricspb.ExportMetricsServiceRequest{}
+		require.NoError(t, proto.Unmarshal(b, req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	s, err := New("dummy-service", "v0.0.0",
+		WithOTLPMetrics(collector.URL+"/v1/metrics", OTLPMetricsHeader("Authorization", "secret")),
+	)
+	require.NoError(t, err)
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "jobs_total", Help: "Jobs."}, []string{"status"})
+	counter.WithLabelValues("done").Add(3)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration_seconds", Buckets: []float64{1, 5}})
+	for _, v := range []float64{0.5, 2, 3, 10} {
+		histogram.Observe(v)
+	}
+	registry.MustRegister(counter, histogram)
+	s.AddGatherer(registry)
+
+	// Metrics are exported when the service shuts down.
+	s.Shutdown()
+	s.Run()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 1)
+	rm := requests[0].GetResourceMetrics()[0]
+	assert.Equal(t, "service.name", rm.GetResource().GetAttributes()[0].GetKey())
+	assert.Equal(t, "dummy-service", rm.GetResource().GetAttributes()[0].GetValue().GetStringValue())
+
+	metrics := map[string]*metricspb.Metric{}
+	for _, m := range rm.GetScopeMetrics()[0].GetMetrics() {
+		metrics[m.GetName()] = m
+	}
+
+	sum := metrics["jobs_total"].GetSum()
+	require.NotNil(t, sum)
+	assert.True(t, sum.GetIsMonotonic())
+	assert.Equal(t, 3.0, sum.GetDataPoints()[0].GetAsDouble())
+	assert.Equal(t, "status", sum.GetDataPoints()[0].GetAttributes()[0].GetKey())
+
+	dp := metrics["duration_seconds"].GetHistogram().GetDataPoints()[0]
+	assert.Equal(t, uint64(4), dp.GetCount())
+	assert.Equal(t, 15.5, dp.GetSum())
+	assert.Equal(t, []float64{1, 5}, dp.GetExplicitBounds())
+	assert.Equal(t, []uint64{1, 2, 1}, dp.GetBucketCounts())
+}
@@ -0,0 +1,162 @@
+package svc
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// ByteSize is a number of bytes parsed from a size with an optional unit, e.g.
+// `512MiB` or `1.5GB`. Decimal (kB, MB, GB, TB) and binary (KiB, MiB, GiB, TiB)
+// units are supported, units are case-insensitive.
+type ByteSize uint64
+
+// Byte size units.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"b":   Byte,
+	"kb":  KB,
+	"mb":  MB,
+	"gb":  GB,
+	"tb":  TB,
+	"kib": KiB,
+	"mib": MiB,
+	"gib": GiB,
+	"tib": TiB,
+}
+
+// ParseByteSize parses a size with an optional unit, e.g. `512MiB`.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, s[i:])
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	size := n * float64(multiplier)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid byte size %q: out of range", s)
+	}
+	return ByteSize(size), nil
+}
+
+// String returns the size in the largest binary unit dividing it, e.g.
+// `512MiB`.
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		size ByteSize
+		name string
+	}{{TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"}} {
+		if b >= u.size && b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// DefaultParsers returns parsers for common configuration types to be used
+// with LoadFromEnvWithParsers or ConfigParsers. They are registered by default
+// with LoadConfig and WithConfig. Fields can be of these types or pointers to
+// them:
+//   - url.URL, e.g. `https://example.com/path`
+//   - net.IPNet, from CIDR notation, e.g. `10.0.0.0/8`
+//   - time.Location, from IANA time zone names, e.g. `Europe/Stockholm`
+//   - regexp.Regexp, e.g. `^[a-z]+$`
+//   - ByteSize, e.g. `512MiB`
+//   - map[string]string, from `k=v` pairs separated by commas, e.g. `a=1,b=2`
+//
+// net.IP and zapcore.Level are supported without custom parsers as they
+// implement encoding.TextUnmarshaler.
+func DefaultParsers() map[reflect.Type]env.ParserFunc {
+	return map[reflect.Type]env.ParserFunc{
+		reflect.TypeOf(url.URL{}):           parseURL,
+		reflect.TypeOf(net.IPNet{}):         parseCIDR,
+		reflect.TypeOf(time.Location{}):     parseLocation,
+		reflect.TypeOf(regexp.Regexp{}):     parseRegexp,
+		reflect.TypeOf(ByteSize(0)):         parseByteSize,
+		reflect.TypeOf(map[string]string{}): parseStringMap,
+	}
+}
+
+func parseURL(v string) (interface{}, error) {
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+	return *u, nil
+}
+
+func parseCIDR(v string) (interface{}, error) {
+	_, ipNet, err := net.ParseCIDR(v)
+	if err != nil {
+		return nil, err
+	}
+	return *ipNet, nil
+}
+
+func parseLocation(v string) (interface{}, error) {
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		return nil, err
+	}
+	return *loc, nil
+}
+
+func parseRegexp(v string) (interface{}, error) {
+	re, err := regexp.Compile(v)
+	if err != nil {
+		return nil, err
+	}
+	return *re, nil
+}
+
+func parseByteSize(v string) (interface{}, error) {
+	return ParseByteSize(v)
+}
+
+func parseStringMap(v string) (interface{}, error) {
+	m := map[string]string{}
+	for _, pair := range strings.Split(v, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, val, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid key-value pair %q", pair)
+		}
+		m[k] = strings.TrimSpace(val)
+	}
+	return m, nil
+}
@@ -0,0 +1,102 @@
+package svc
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ByteSize
+	}{
+		{in: "0", want: 0},
+		{in: "100", want: 100},
+		{in: "100B", want: 100},
+		{in: "512MiB", want: 512 * MiB},
+		{in: "512 mib", want: 512 * MiB},
+		{in: "1.5GB", want: 1500 * MB},
+		{in: "2KiB", want: 2048},
+		{in: "1TB", want: TB},
+	}
+	for _, tc := range tests {
+		got, err := ParseByteSize(tc.in)
+		require.NoError(t, err, tc.in)
+		require.Equal(t, tc.want, got, tc.in)
+	}
+
+	for _, invalid := range []string{"", "MiB", "12XB", "1.2.3KB", "-1KB", "1e30TiB"} {
+		_, err := ParseByteSize(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	require.Equal(t, "512MiB", (512 * MiB).String())
+	require.Equal(t, "1500KiB", (1500 * KiB).String())
+	require.Equal(t, "1000B", KB.String())
+}
+
+func TestDefaultParsers(t *testing.T) {
+	type config struct {
+		URL      url.URL            `env:"URL"`
+		URLPtr   *url.URL           `env:"URL_PTR" envDefault:"http://localhost:8080"`
+		IP       net.IP             `env:"IP"`
+		Network  net.IPNet          `env:"NETWORK"`
+		Networks []*net.IPNet       `env:"NETWORKS"`
+		Location *time.Location     `env:"LOCATION"`
+		Pattern  *regexp.Regexp     `env:"PATTERN"`
+		Unset    *regexp.Regexp     `env:"UNSET"`
+		Level    zapcore.Level      `env:"LEVEL"`
+		MaxSize  ByteSize           `env:"MAX_SIZE" envDefault:"1MiB"`
+		Labels   map[string]string  `env:"LABELS"`
+		SizePtr  *ByteSize          `env:"SIZE_PTR" envDefault:"2KB"`
+		NoSize   *ByteSize          `env:"NO_SIZE"`
+		Tags     *map[string]string `env:"TAGS,required"`
+	}
+	t.Setenv("URL", "https://user@example.com/path?q=1")
+	t.Setenv("IP", "10.1.2.3")
+	t.Setenv("NETWORK", "10.0.0.0/8")
+	t.Setenv("NETWORKS", "192.168.0.0/16,fd00::/8")
+	t.Setenv("LOCATION", "Europe/Stockholm")
+	t.Setenv("PATTERN", "^[a-z]+$")
+	t.Setenv("LEVEL", "warn")
+	t.Setenv("LABELS", "team=platform, env = dev")
+	t.Setenv("TAGS", "a=1")
+
+	var cfg config
+	require.NoError(t, LoadConfig(&cfg))
+
+	require.Equal(t, "example.com", cfg.URL.Host)
+	require.Equal(t, "http://localhost:8080", cfg.URLPtr.String())
+	require.Equal(t, "10.1.2.3", cfg.IP.String())
+	require.Equal(t, "10.0.0.0/8", cfg.Network.String())
+	require.Len(t, cfg.Networks, 2)
+	require.Equal(t, "fd00::/8", cfg.Networks[1].String())
+	require.Equal(t, "Europe/Stockholm", cfg.Location.String())
+	require.True(t, cfg.Pattern.MatchString("abc"))
+	require.Nil(t, cfg.Unset)
+	require.Equal(t, zapcore.WarnLevel, cfg.Level)
+	require.Equal(t, MiB, cfg.MaxSize)
+	require.Equal(t, map[string]string{"team": "platform", "env": "dev"}, cfg.Labels)
+	require.Equal(t, 2*KB, *cfg.SizePtr)
+	require.Nil(t, cfg.NoSize)
+	require.Equal(t, map[string]string{"a": "1"}, *cfg.Tags)
+
+	for key, invalid := range map[string]string{
+		"NETWORK":  "10.0.0.0",
+		"LOCATION": "Mars/Olympus_Mons",
+		"PATTERN":  "[",
+		"MAX_SIZE": "1 lightyear",
+		"LABELS":   "team",
+		"SIZE_PTR": "2 parsecs",
+	} {
+		t.Setenv(key, invalid)
+		require.Error(t, LoadConfig(&config{}), key)
+		t.Setenv(key, "")
+	}
+}
@@ -0,0 +1,330 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// Results of a scheduled job run, as reported by the scheduler's metrics.
+const (
+	jobResultSuccess = "success"
+	jobResultFailure = "failure"
+	jobResultTimeout = "timeout"
+	jobResultPanic   = "panic"
+)
+
+// ScheduledJob is a job run by a Scheduler. The context is canceled once the
+// job's timeout elapses.
+type ScheduledJob func(ctx context.Context) error
+
+// OverlapPolicy defines what happens when a job is due while its previous run
+// is still in progress.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run. It is the default.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue starts the run once the previous one completed. At most one
+	// run is queued.
+	OverlapQueue
+	// OverlapConcurrent starts the run concurrently to the previous one.
+	OverlapConcurrent
+)
+
+// JobOption defines the option type of Scheduler's AddCron and AddInterval.
+type JobOption func(*scheduledJob)
+
+// JobTimeout sets the timeout of a single run of the job, after which its
+// context gets canceled. By default, runs have no timeout.
+func JobTimeout(d time.Duration) JobOption {
+	return func(j *scheduledJob) {
+		j.timeout = d
+	}
+}
+
+// JobJitter delays each run of the job by a random duration up to the given
+// one, e.g. to spread the load of replicas running the same schedule.
+func JobJitter(d time.Duration) JobOption {
+	return func(j *scheduledJob) {
+		j.jitter = d
+	}
+}
+
+// JobOverlap sets what happens when the job is due while its previous run is
+// still in progress. Defaults to OverlapSkip.
+func JobOverlap(policy OverlapPolicy) JobOption {
+	return func(j *scheduledJob) {
+		j.overlap = policy
+	}
+}
+
+// SchedulerOption defines the option type of NewScheduler.
+type SchedulerOption func(*Scheduler)
+
+// SchedulerClock sets the clock scheduling the jobs, e.g. a fake clock in
+// tests.
+func SchedulerClock(clock Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+// schedule returns the next activation time later than the given time.
+type schedule interface {
+	Next(time.Time) time.Time
+}
+
+// intervalSchedule is a schedule with a fixed interval.
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+type scheduledJob struct {
+	name     string
+	schedule schedule
+	run      ScheduledJob
+	timeout  time.Duration
+	jitter   time.Duration
+	overlap  OverlapPolicy
+
+	mu      sync.Mutex
+	running int
+	queued  bool
+}
+
+var (
+	_ Worker   = (*Scheduler)(nil)
+	_ Gatherer = (*Scheduler)(nil)
+)
+
+// Scheduler is a worker running jobs on cron expressions or fixed intervals.
+// It recovers panicking jobs and, when terminated, stops scheduling new runs
+// and waits for the runs in progress to complete. Its metrics are gathered by
+// the service it is added to.
+type Scheduler struct {
+	clock  Clock
+	logger *zap.Logger
+	jobs   []*scheduledJob
+
+	registry    *prometheus.Registry
+	runs        *prometheus.CounterVec
+	skipped     *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	lastSuccess *prometheus.GaugeVec
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	mu       sync.Mutex
+	stopped  bool
+	loops    sync.WaitGroup
+	inFlight sync.WaitGroup
+}
+
+// NewScheduler returns a Scheduler without jobs.
+func NewScheduler(opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		clock:    realClock{},
+		registry: prometheus.NewRegistry(),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "scheduler_job_runs_total",
+			Help: "Number of completed runs of scheduled jobs by result.",
+		}, []string{"job", "result"}),
+		skipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "scheduler_job_skipped_total",
+			Help: "Number of runs of scheduled jobs skipped as the previous run was still in progress.",
+		}, []string{"job"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "scheduler_job_duration_seconds",
+			Help: "Duration of the runs of scheduled jobs.",
+		}, []string{"job"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "scheduler_job_last_success_timestamp_seconds",
+			Help: "Time of the last successful run of scheduled jobs.",
+		}, []string{"job"}),
+		stop: make(chan struct{}),
+	}
+	s.registry.MustRegister(s.runs, s.skipped, s.duration, s.lastSuccess)
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// AddCron adds a job run on the given cron expression, in the standard
+// five-field format or a descriptor like `@hourly`, optionally preceded by a
+// time zone, e.g. `CRON_TZ=Europe/Stockholm 0 6 * * *`. Jobs must be added
+// before the scheduler runs.
+func (s *Scheduler) AddCron(name, spec string, job ScheduledJob, opts ...JobOption) error {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression %q of job %s: %w", spec, name, err)
+	}
+	s.addJob(name, sched, job, opts)
+	return nil
+}
+
+// AddInterval adds a job run at the given positive interval, the first time
+// one interval after the scheduler started. Jobs must be added before the
+// scheduler runs.
+func (s *Scheduler) AddInterval(name string, interval time.Duration, job ScheduledJob, opts ...JobOption) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval of job %s must be positive, got %s", name, interval)
+	}
+	s.addJob(name, intervalSchedule(interval), job, opts)
+	return nil
+}
+
+func (s *Scheduler) addJob(name string, sched schedule, run ScheduledJob, opts []JobOption) {
+	j := &scheduledJob{name: name, schedule: sched, run: run}
+	for _, o := range opts {
+		o(j)
+	}
+	s.jobs = append(s.jobs, j)
+}
+
+// Init implements the Worker interface.
+func (s *Scheduler) Init(logger *zap.Logger) error {
+	s.logger = logger
+
+	return nil
+}
+
+// Run implements the Worker interface.
+func (s *Scheduler) Run() error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	for _, j := range s.jobs {
+		s.loops.Add(1)
+		go s.loop(j)
+	}
+	s.mu.Unlock()
+
+	<-s.stop
+	return nil
+}
+
+// Terminate implements the Worker interface. It waits for the runs in
+// progress to complete, dropping queued ones.
+func (s *Scheduler) Terminate() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.loops.Wait()
+	s.inFlight.Wait()
+
+	return nil
+}
+
+// Gatherer implements the Gatherer interface.
+func (s *Scheduler) Gatherer() prometheus.Gatherer {
+	return s.registry
+}
+
+// loop triggers the runs of a job until the scheduler gets terminated.
+func (s *Scheduler) loop(j *scheduledJob) {
+	defer s.loops.Done()
+
+	for {
+		now := s.clock.Now()
+		delay := j.schedule.Next(now).Sub(now)
+		if j.jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(j.jitter))) //nolint:gosec
+		}
+		select {
+		case <-s.clock.After(delay):
+			s.trigger(j)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// trigger starts a run of the job according to its overlap policy.
+func (s *Scheduler) trigger(j *scheduledJob) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running > 0 {
+		switch j.overlap {
+		case OverlapSkip:
+			s.skipped.WithLabelValues(j.name).Inc()
+			s.logger.Warn("Skipping job run, previous run still in progress", zap.String("job", j.name))
+			return
+		case OverlapQueue:
+			j.queued = true
+			return
+		case OverlapConcurrent:
+		}
+	}
+	s.start(j)
+}
+
+// start starts a run of the job. It must be called with the job's mutex held.
+func (s *Scheduler) start(j *scheduledJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+
+	j.running++
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		s.execute(j)
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.running--
+		if j.queued && j.running == 0 {
+			j.queued = false
+			s.start(j)
+		}
+	}()
+}
+
+// execute runs the job once, recording its result.
+func (s *Scheduler) execute(j *scheduledJob) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if j.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+	}
+	defer cancel()
+
+	start := s.clock.Now()
+	result := jobResultSuccess
+	defer func() {
+		if r := recover(); r != nil {
+			result = jobResultPanic
+			s.logger.Error("Job panicked", zap.String("job", j.name), zap.Any("panic", r), zap.Stack("stack"))
+		}
+		end := s.clock.Now()
+		s.runs.WithLabelValues(j.name, result).Inc()
+		s.duration.WithLabelValues(j.name).Observe(end.Sub(start).Seconds())
+		if result == jobResultSuccess {
+			s.lastSuccess.WithLabelValues(j.name).Set(float64(end.Unix()))
+		}
+	}()
+
+	if err := j.run(ctx); err != nil {
+		result = jobResultFailure
+		if ctx.Err() == context.DeadlineExceeded {
+			result = jobResultTimeout
+		}
+		s.logger.Error("Job failed", zap.String("job", j.name), zap.String("result", result), zap.Error(err))
+	}
+}
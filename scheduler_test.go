@@ -0,0 +1,214 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/voi-oss/svc/svctest/fake"
+)
+
+func startScheduler(t *testing.T, s *Scheduler, clock *fake.Clock, jobs int) {
+	t.Helper()
+	require.NoError(t, s.Init(zap.NewNop()))
+	go func() { _ = s.Run() }()
+	clock.BlockUntil(jobs)
+}
+
+func schedulerMetric(t *testing.T, s *Scheduler, name string, labels ...string) float64 {
+	t.Helper()
+	families, err := s.Gatherer().Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			if !hasLabels(m, labels) {
+				continue
+			}
+			switch {
+			case m.GetCounter() != nil:
+				return m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				return m.GetGauge().GetValue()
+			case m.GetHistogram() != nil:
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return 0
+}
+
+func hasLabels(m *dto.Metric, labels []string) bool {
+	values := map[string]string{}
+	for _, l := range m.GetLabel() {
+		values[l.GetName()] = l.GetValue()
+	}
+	for i := 0; i+1 < len(labels); i += 2 {
+		if values[labels[i]] != labels[i+1] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSchedulerInterval(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	s := NewScheduler(SchedulerClock(clock))
+	runs := make(chan struct{})
+	require.NoError(t, s.AddInterval("interval", time.Minute, func(context.Context) error {
+		runs <- struct{}{}
+		return nil
+	}))
+	startScheduler(t, s, clock, 1)
+
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		<-runs
+	}
+	require.NoError(t, s.Terminate())
+
+	assert.Equal(t, 3.0, schedulerMetric(t, s, "scheduler_job_runs_total", "job", "interval", "result", "success"))
+	assert.Equal(t, 3.0, schedulerMetric(t, s, "scheduler_job_duration_seconds", "job", "interval"))
+	assert.Equal(t, float64(3*60), schedulerMetric(t, s, "scheduler_job_last_success_timestamp_seconds", "job", "interval"))
+}
+
+func TestSchedulerInvalidInterval(t *testing.T) {
+	s := NewScheduler()
+	assert.Error(t, s.AddInterval("zero", 0, func(context.Context) error { return nil }))
+	assert.Error(t, s.AddInterval("negative", -time.Second, func(context.Context) error { return nil }))
+	assert.Empty(t, s.jobs)
+}
+
+func TestSchedulerCron(t *testing.T) {
+	s := NewScheduler()
+	assert.Error(t, s.AddCron("invalid", "* * *", func(context.Context) error { return nil }))
+	require.NoError(t, s.AddCron("daily", "CRON_TZ=Europe/Stockholm 0 6 * * *", func(context.Context) error { return nil }))
+
+	next := s.jobs[0].schedule.Next(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 1, 2, 5, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	tests := []struct {
+		policy  OverlapPolicy
+		runs    int32
+		skipped float64
+	}{
+		{policy: OverlapSkip, runs: 1, skipped: 2},
+		{policy: OverlapQueue, runs: 2, skipped: 0},
+		{policy: OverlapConcurrent, runs: 3, skipped: 0},
+	}
+	for _, tt := range tests {
+		clock := fake.NewClock(time.Unix(0, 0))
+		s := NewScheduler(SchedulerClock(clock))
+		release := make(chan struct{})
+		var runs int32
+		require.NoError(t, s.AddInterval("overlap", time.Second, func(context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return nil
+		}, JobOverlap(tt.policy)))
+		startScheduler(t, s, clock, 1)
+
+		for i := 0; i < 3; i++ {
+			clock.BlockUntil(1)
+			clock.Advance(time.Second)
+		}
+		clock.BlockUntil(1)
+		close(release)
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == tt.runs }, time.Second, time.Millisecond)
+		require.NoError(t, s.Terminate())
+
+		assert.Equal(t, tt.runs, atomic.LoadInt32(&runs), "policy %d", tt.policy)
+		assert.Equal(t, tt.skipped, schedulerMetric(t, s, "scheduler_job_skipped_total", "job", "overlap"), "policy %d", tt.policy)
+	}
+}
+
+func TestSchedulerResults(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	s := NewScheduler(SchedulerClock(clock))
+	done := make(chan struct{}, 3)
+	require.NoError(t, s.AddInterval("failing", time.Second, func(context.Context) error {
+		defer func() { done <- struct{}{} }()
+		return errors.New("failed")
+	}))
+	require.NoError(t, s.AddInterval("panicking", time.Second, func(context.Context) error {
+		defer func() { done <- struct{}{} }()
+		panic("oops")
+	}))
+	require.NoError(t, s.AddInterval("slow", time.Second, func(ctx context.Context) error {
+		defer func() { done <- struct{}{} }()
+		<-ctx.Done()
+		return ctx.Err()
+	}, JobTimeout(time.Millisecond)))
+	startScheduler(t, s, clock, 3)
+
+	clock.Advance(time.Second)
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+	require.NoError(t, s.Terminate())
+
+	assert.Equal(t, 1.0, schedulerMetric(t, s, "scheduler_job_runs_total", "job", "failing", "result", "failure"))
+	assert.Equal(t, 1.0, schedulerMetric(t, s, "scheduler_job_runs_total", "job", "panicking", "result", "panic"))
+	assert.Equal(t, 1.0, schedulerMetric(t, s, "scheduler_job_runs_total", "job", "slow", "result", "timeout"))
+}
+
+func TestSchedulerTerminateWaitsForRuns(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	s := NewScheduler(SchedulerClock(clock))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var completed int32
+	require.NoError(t, s.AddInterval("long", time.Second, func(context.Context) error {
+		close(started)
+		<-release
+		atomic.StoreInt32(&completed, 1)
+		return nil
+	}))
+	startScheduler(t, s, clock, 1)
+	clock.Advance(time.Second)
+	<-started
+
+	terminated := make(chan error)
+	go func() { terminated <- s.Terminate() }()
+	select {
+	case <-terminated:
+		t.Fatal("Terminate returned before the job run completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-terminated)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&completed))
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	s := NewScheduler(SchedulerClock(clock))
+	runs := make(chan struct{}, 1)
+	require.NoError(t, s.AddInterval("jittered", time.Minute, func(context.Context) error {
+		runs <- struct{}{}
+		return nil
+	}, JobJitter(time.Minute)))
+	startScheduler(t, s, clock, 1)
+
+	clock.Advance(time.Minute - time.Nanosecond)
+	select {
+	case <-runs:
+		t.Fatal("job ran before its interval elapsed")
+	default:
+	}
+	clock.Advance(time.Minute)
+	<-runs
+	require.NoError(t, s.Terminate())
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/avast/retry-go/v4"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/zap"
 )
 
@@ -33,22 +35,40 @@ type SVC struct {
 
 	TerminationGracePeriod time.Duration
 	TerminationWaitPeriod  time.Duration
+	clock                  Clock
 	signals                chan os.Signal
+	reloadOnSIGHUP         bool
+	started                chan struct{}
 
 	logger             *zap.Logger
+	logSink            *logSink
+	baseLogger         *zap.Logger
+	loggerWrappers     []zap.Option
 	zapOpts            []zap.Option
 	stdLogger          *log.Logger
 	atom               zap.AtomicLevel
 	loggerRedirectUndo func()
+	slogDefault        bool
+	logCorrelation     logCorrelation
+	gcpProject         string
 
 	workers             map[string]Worker
 	workerInitRetryOpts map[string][]retry.Option
 	workersAdded        []string
 	workersInitialized  []string
+	job                 string
+	exitCode            int
 
 	gatherers        prometheus.Gatherers
 	internalRegister *prometheus.Registry
 	promHander       http.Handler
+
+	commandArgs    []string
+	config         *serviceConfig
+	logConfigOnRun bool
+
+	tracing *tracing
+	tracer  trace.Tracer
 }
 
 // New instantiates a new service by parsing configuration and initializing a
@@ -62,12 +82,20 @@ func New(name, version string, opts ...Option) (*SVC, error) {
 
 		TerminationGracePeriod: defaultTerminationGracePeriod,
 		TerminationWaitPeriod:  defaultTerminationWaitPeriod,
+		clock:                  realClock{},
 		signals:                make(chan os.Signal, 3),
+		started:                make(chan struct{}),
 
 		workers:             map[string]Worker{},
 		workersAdded:        []string{},
 		workersInitialized:  []string{},
 		workerInitRetryOpts: map[string][]retry.Option{},
+
+		tracer: noop.NewTracerProvider().Tracer(tracerName),
+
+		logSink:        &logSink{w: os.Stdout},
+		logCorrelation: defaultLogCorrelation,
+		gcpProject:     defaultGCPProject(),
 	}
 
 	if err := WithDevelopmentLogger()(s); err != nil {
@@ -116,15 +144,30 @@ func (s *SVC) AddWorkerWithInitRetry(name string, w Worker, retryOpts []retry.Op
 	s.workerInitRetryOpts[name] = retryOpts
 }
 
+// AddJob adds a named worker to the service whose Run completion ends the
+// service, as for batch or cron jobs. Once the job's Run returns, the other
+// workers get terminated, as does the job itself, and ExitCode reflects the
+// job's result. Only one job can be added.
+func (s *SVC) AddJob(name string, w Worker) {
+	if s.job != "" {
+		s.logger.Fatal("Duplicate jobs!", zap.String("name", name), zap.String("job", s.job), zap.Stack("stacktrace"))
+	}
+	s.AddWorker(name, w)
+	s.job = name
+}
+
 func (s *SVC) AddGatherer(gatherer prometheus.Gatherer) {
 	s.promHander = nil
 	s.gatherers = append(s.gatherers, gatherer)
 }
 
-// Run runs the service until either receiving an interrupt or a worker
-// terminates.
+// Run runs the service until either receiving an interrupt, a worker
+// terminates, or the job added by AddJob completes.
 func (s *SVC) Run() {
 	s.logger.Info("Starting up service")
+	if s.logConfigOnRun {
+		s.logConfig()
+	}
 
 	defer func() {
 		s.logger.Info("Shutting down service", zap.Duration("termination_grace_period", s.TerminationGracePeriod))
@@ -138,24 +181,33 @@ func (s *SVC) Run() {
 	for _, name := range s.workersAdded {
 		s.logger.Debug("Initializing worker", zap.String("worker", name))
 		w := s.workers[name]
-		var err error
-		if opts, ok := s.workerInitRetryOpts[name]; ok {
-			//nolint:scopelint
-			err = retry.Do(func() error { return w.Init(s.logger.Named(name)) }, opts...)
-		} else {
-			err = w.Init(s.logger.Named(name))
-		}
+		err := s.initWorker(name, func() error {
+			if opts, ok := s.workerInitRetryOpts[name]; ok {
+				//nolint:scopelint
+				return retry.Do(func() error { return w.Init(s.logger.Named(name)) }, opts...)
+			}
+			return w.Init(s.logger.Named(name))
+		})
 		if err != nil {
 			s.logger.Error("Could not initialize service", zap.String("worker", name), zap.Error(err))
+			s.exitCode = exitError
 			return
 		}
 		s.workersInitialized = append(s.workersInitialized, name)
 	}
 
 	errs := make(chan error)
+	jobDone := make(chan error, 1)
 	wg := sync.WaitGroup{}
 	for name, w := range s.workers {
 		wg.Add(1)
+		if name == s.job {
+			go func(name string, w Worker) {
+				defer wg.Done()
+				jobDone <- s.runJob(name, w)
+			}(name, w)
+			continue
+		}
 		go func(name string, w Worker) {
 			defer s.recoverWait(name, &wg, errs)
 			if err := w.Run(); err != nil {
@@ -165,7 +217,12 @@ func (s *SVC) Run() {
 		}(name, w)
 	}
 
-	signal.Notify(s.signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	shutdownSignals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	if !s.reloadOnSIGHUP {
+		shutdownSignals = append(shutdownSignals, syscall.SIGHUP)
+	}
+	signal.Notify(s.signals, shutdownSignals...)
+	close(s.started)
 
 	select {
 	case err := <-errs:
@@ -175,9 +232,46 @@ func (s *SVC) Run() {
 		s.logger.Warn("Worker context canceled", zap.Error(err))
 	case sig := <-s.signals:
 		s.logger.Warn("Caught signal", zap.String("signal", sig.String()))
+		if s.job != "" {
+			s.logger.Error("Job interrupted", zap.String("job", s.job))
+			s.exitCode = exitError
+		}
+	case err := <-jobDone:
+		s.finishJob(err)
 	case <-waitGroupToChan(&wg):
 		s.logger.Info("All workers have finished")
+		if s.job != "" {
+			// The job's result is sent before it is marked as done.
+			s.finishJob(<-jobDone)
+		}
+	}
+}
+
+// ExitCode returns the exit status of the service once Run returned: 1 if a
+// worker failed to initialize or the job added by AddJob failed or got
+// interrupted, 0 otherwise.
+func (s *SVC) ExitCode() int {
+	return s.exitCode
+}
+
+// runJob runs the job's worker, returning a panic as an error.
+func (s *SVC) runJob(name string, w Worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("recover panic", zap.String("worker", name), zap.Any("panic", r), zap.Stack("stack"))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return w.Run()
+}
+
+func (s *SVC) finishJob(err error) {
+	if err != nil {
+		s.logger.Error("Job failed", zap.String("job", s.job), zap.Error(err))
+		s.exitCode = exitError
+		return
 	}
+	s.logger.Info("Job completed", zap.String("job", s.job))
 }
 
 // Shutdown signals the framework to terminate any already started workers and
@@ -189,6 +283,64 @@ func (s *SVC) Shutdown() {
 	s.signals <- syscall.SIGTERM
 }
 
+// Signal delivers the signal to the service as if the process received it:
+// SIGHUP reloads the configuration if WithConfigReload is used, any other
+// signal shuts the service down.
+func (s *SVC) Signal(sig os.Signal) {
+	if w, ok := s.workers[configWatcherWorkerName].(*configWatcher); ok && sig == syscall.SIGHUP {
+		select {
+		case w.signals <- sig:
+		default:
+		}
+		return
+	}
+	s.signals <- sig
+}
+
+// Started returns a channel that is closed once all workers are initialized
+// and running. It is never closed if the initialization of a worker fails.
+func (s *SVC) Started() <-chan struct{} {
+	return s.started
+}
+
+// Ready returns the errors of the workers implementing the Healther interface
+// that are not healthy, as served by the ready probe of WithHealthz.
+func (s *SVC) Ready() error {
+	return errors.Join(s.workerErrors(func(w Worker) error {
+		if hw, ok := w.(Healther); ok {
+			return hw.Healthy()
+		}
+		return nil
+	})...)
+}
+
+// Gatherer returns the Prometheus gatherers of the service's metrics.
+func (s *SVC) Gatherer() prometheus.Gatherer {
+	return s.gatherers
+}
+
+// HTTPAddr returns the address the internal HTTP server added by
+// WithHTTPServer is bound to, e.g. to find out the port of a server listening
+// on port "0". It is empty until the server's worker is initialized.
+func (s *SVC) HTTPAddr() string {
+	if w, ok := s.workers[internalHTTPServerName].(*httpServer); ok {
+		return w.listenAddr()
+	}
+	return ""
+}
+
+// workerErrors returns the errors returned by check for each worker, prefixed
+// by the worker's name.
+func (s *SVC) workerErrors(check func(Worker) error) []error {
+	var errs []error
+	for n, w := range s.workers {
+		if err := check(w); err != nil {
+			errs = append(errs, fmt.Errorf("worker %s: %s", n, err))
+		}
+	}
+	return errs
+}
+
 // MustInit is a convenience function to check for and halt on errors.
 func MustInit(s *SVC, err error) *SVC {
 	if err != nil {
@@ -206,6 +358,17 @@ func (s *SVC) Logger() *zap.Logger {
 	return s.logger
 }
 
+// Config returns the configuration loaded by the WithConfig option, as a
+// pointer of the type passed to it. When reloaded, the latest configuration is
+// returned. Config returns nil if WithConfig is not used.
+func (s *SVC) Config() interface{} {
+	if s.config == nil {
+		return nil
+	}
+	config, _ := s.config.current()
+	return config
+}
+
 func (s *SVC) terminateWorkers() {
 	s.logger.Info("Terminating workers down service", zap.Duration("termination_grace_period", s.TerminationGracePeriod))
 
@@ -214,7 +377,7 @@ func (s *SVC) terminateWorkers() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		time.Sleep(s.TerminationWaitPeriod)
+		s.clock.Sleep(s.TerminationWaitPeriod)
 		for _, name := range s.workersInitialized {
 			defer func(name string) {
 				w := s.workers[name]
@@ -227,14 +390,15 @@ func (s *SVC) terminateWorkers() {
 			}(name)
 		}
 	}()
-	waitGroupTimeout(&wg, s.TerminationGracePeriod)
+	waitGroupTimeout(&wg, s.TerminationGracePeriod, s.clock)
 	s.logger.Info("All workers terminated")
+	s.shutdownTracing()
 }
 
-func waitGroupTimeout(wg *sync.WaitGroup, d time.Duration) {
+func waitGroupTimeout(wg *sync.WaitGroup, d time.Duration, clock Clock) {
 	select {
 	case <-waitGroupToChan(wg):
-	case <-time.After(d):
+	case <-clock.After(d):
 	}
 }
 
@@ -2,6 +2,7 @@ package svc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/avast/retry-go/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/voi-oss/svc/svctest/fake"
 	"go.uber.org/zap"
 )
 
@@ -18,49 +20,64 @@ func TestWorkerInitOrder(t *testing.T) {
 	s, err := New("dummy-name", "dummy-version")
 	require.NoError(t, err)
 
-	var actualSeq []string
-
-	w1 := &WorkerMock{
-		InitFunc: func(*zap.Logger) error {
-			actualSeq = append(actualSeq, "w1Init")
-			return nil
-		},
-		RunFunc:       func() error { return nil },
-		TerminateFunc: func() error { return nil },
-	}
-	w2 := &WorkerMock{
-		InitFunc: func(*zap.Logger) error {
-			actualSeq = append(actualSeq, "w2Init")
-			return nil
-		},
-		RunFunc:       func() error { return nil },
-		TerminateFunc: func() error { return nil },
-	}
-	w3 := &WorkerMock{
-		InitFunc: func(*zap.Logger) error {
-			actualSeq = append(actualSeq, "w3Init")
-			return nil
-		},
-		RunFunc:       func() error { return nil },
-		TerminateFunc: func() error { return nil },
+	rec := fake.NewRecorder()
+	for _, name := range []string{"w1", "w2", "w3"} {
+		s.AddWorker(name, fake.NewWorker(name, fake.Record(rec), fake.RunFor(0)))
 	}
 
 	// Act
 
-	s.AddWorker("w1", w1)
-	s.AddWorker("w2", w2)
-	s.AddWorker("w3", w3)
 	s.Run()
 
 	// Assert
 
-	expectedSeq := []string{
-		"w1Init",
-		"w2Init",
-		"w3Init",
+	assert.Equal(t, []string{"w1.Init", "w2.Init", "w3.Init"}, rec.Sequence()[:3])
+}
+
+func TestWorkerTerminationOrder(t *testing.T) {
+	s, err := New("dummy-name", "dummy-version")
+	require.NoError(t, err)
+
+	rec := fake.NewRecorder()
+	w1 := fake.NewWorker("w1", fake.Record(rec))
+	w2 := fake.NewWorker("w2", fake.Record(rec))
+	w3 := fake.NewWorker("w3", fake.Record(rec), fake.InitError(errors.New("boom")))
+	s.AddWorker(w1.Name(), w1)
+	s.AddWorker(w2.Name(), w2)
+	s.AddWorker(w3.Name(), w3)
+
+	s.Run()
+
+	// Only initialized workers get terminated, in reverse order.
+	assert.Equal(t, []string{"w1.Init", "w2.Init", "w3.Init", "w2.Terminate", "w1.Terminate"}, rec.Sequence())
+}
+
+func TestTerminationGracePeriod(t *testing.T) {
+	s, err := New("dummy-name", "dummy-version", WithTerminationGracePeriod(50*time.Millisecond))
+	require.NoError(t, err)
+
+	// Only the worker's termination hangs on the fake clock.
+	clock := fake.NewClock(time.Now())
+	w := fake.NewWorker("hanging", fake.WithClock(clock), fake.TerminateDelay(time.Hour))
+	s.AddWorker(w.Name(), w)
+	s.Shutdown()
+
+	start := time.Now()
+	s.Run()
+
+	assert.Less(t, time.Since(start), time.Second)
+	terminated := false
+	for _, c := range w.Calls() {
+		if c.Method == fake.MethodTerminate {
+			assert.True(t, c.End.IsZero(), "terminate still hanging")
+			terminated = true
+		}
 	}
+	assert.True(t, terminated, "not terminated")
 
-	assert.Equal(t, expectedSeq, actualSeq)
+	// Let the hanging termination return.
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
 }
 
 func TestShutdown(t *testing.T) {
@@ -152,45 +169,26 @@ func (w *WorkerMock) Healthy() error {
 }
 
 func TestSVC_AddWorkerWithInitRetry(t *testing.T) {
-	var attempts uint
 	tests := []struct {
 		name             string
-		w                Worker
+		w                *fake.Worker
 		retryOpts        []retry.Option
-		expectedAttempts uint
+		expectedAttempts int
 	}{
 		{
-			name: "succeeds after 3 attempts, with max  10 attempts",
-			w: &WorkerMock{
-				InitFunc: func(*zap.Logger) error {
-					if attempts < 3 {
-						attempts++
-						return fmt.Errorf("failed")
-					}
-					return nil
-				},
-				TerminateFunc: func() error { return nil },
-				RunFunc:       func() error { return nil },
-			},
+			name:             "succeeds after 3 attempts, with max  10 attempts",
+			w:                fake.NewWorker("test", fake.InitFailures(2, fmt.Errorf("failed")), fake.RunFor(0)),
 			retryOpts:        []retry.Option{retry.Attempts(10), retry.MaxDelay(1 * time.Millisecond), retry.Delay(1 * time.Millisecond)},
 			expectedAttempts: 3,
 		},
 		{
-			name: "fails after 3 attempts, with max 3 attempts",
-			w: &WorkerMock{
-				InitFunc: func(*zap.Logger) error {
-					attempts++
-					return fmt.Errorf("failed")
-				},
-				TerminateFunc: func() error { return nil },
-				RunFunc:       func() error { return nil },
-			},
+			name:             "fails after 3 attempts, with max 3 attempts",
+			w:                fake.NewWorker("test", fake.InitError(fmt.Errorf("failed"))),
 			retryOpts:        []retry.Option{retry.Attempts(3), retry.MaxDelay(1 * time.Millisecond), retry.Delay(1 * time.Millisecond)},
 			expectedAttempts: 3,
 		},
 	}
 	for _, tt := range tests {
-		attempts = 0
 		tt := tt // not needed because we don't run tests in parallel, but scopelint complains otherwise.
 		t.Run(tt.name, func(t *testing.T) {
 			s, err := New("dummy-name", "dummy-version")
@@ -198,7 +196,110 @@ func TestSVC_AddWorkerWithInitRetry(t *testing.T) {
 
 			s.AddWorkerWithInitRetry("test", tt.w, tt.retryOpts)
 			s.Run()
+
+			var attempts int
+			for _, c := range tt.w.Calls() {
+				if c.Method == fake.MethodInit {
+					attempts++
+				}
+			}
 			require.Equal(t, tt.expectedAttempts, attempts)
 		})
 	}
 }
+
+func TestTerminationPeriodsWithClock(t *testing.T) {
+	clock := fake.NewClock(time.Now())
+	s, err := New("dummy-name", "dummy-version",
+		WithClock(clock),
+		WithTerminationWaitPeriod(35*time.Second),
+		WithTerminationGracePeriod(55*time.Second),
+	)
+	require.NoError(t, err)
+
+	rec := fake.NewRecorder()
+	w := fake.NewWorker("hanging", fake.WithClock(clock), fake.Record(rec), fake.TerminateDelay(time.Hour))
+	s.AddWorker(w.Name(), w)
+	s.Shutdown()
+	terminated := func() bool {
+		for _, c := range rec.Calls() {
+			if c.Method == fake.MethodTerminate {
+				return true
+			}
+		}
+		return false
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run()
+	}()
+
+	// The grace period and the wait period are pending.
+	clock.BlockUntil(2)
+	clock.Advance(34 * time.Second)
+	require.False(t, terminated(), "not terminated during the wait period")
+
+	clock.Advance(time.Second)
+	require.Eventually(t, terminated, time.Second, time.Millisecond)
+
+	select {
+	case <-done:
+		require.FailNow(t, "shut down before the grace period")
+	default:
+	}
+	clock.Advance(20 * time.Second)
+	<-done
+
+	// Let the hanging termination return.
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+}
+
+func TestJob(t *testing.T) {
+	tests := []struct {
+		name             string
+		job              *fake.Worker
+		shutdown         bool
+		expectedExitCode int
+	}{
+		{name: "completed", job: fake.NewWorker("job", fake.RunFor(time.Millisecond)), expectedExitCode: 0},
+		{name: "failed", job: fake.NewWorker("job", fake.RunError(errors.New("boom"))), expectedExitCode: 1},
+		{name: "panicked", job: fake.NewWorker("job", fake.RunPanic("boom")), expectedExitCode: 1},
+		{name: "interrupted", job: fake.NewWorker("job"), shutdown: true, expectedExitCode: 1},
+	}
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New("dummy-name", "dummy-version")
+			require.NoError(t, err)
+
+			helper := fake.NewWorker("helper")
+			s.AddWorker(helper.Name(), helper)
+			s.AddJob(tc.job.Name(), tc.job)
+			if tc.shutdown {
+				s.Shutdown()
+			}
+
+			s.Run()
+
+			assert.Equal(t, tc.expectedExitCode, s.ExitCode())
+			var terminated bool
+			for _, c := range helper.Calls() {
+				terminated = terminated || c.Method == fake.MethodTerminate
+			}
+			assert.True(t, terminated, "helper terminated after the job")
+		})
+	}
+}
+
+func TestJobOnly(t *testing.T) {
+	s, err := New("dummy-name", "dummy-version")
+	require.NoError(t, err)
+	s.AddJob("job", fake.NewWorker("job", fake.RunError(errors.New("boom"))))
+
+	s.Run()
+
+	assert.Equal(t, 1, s.ExitCode())
+}
@@ -0,0 +1,91 @@
+package fake
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake svc.Clock whose time only moves when advanced, e.g. to test
+// termination wait and grace periods without waiting for them.
+type Clock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	waiters []clockWaiter
+}
+
+type clockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewClock returns a fake clock set to the given time.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the clock's time once it got advanced by
+// at least the given duration.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, clockWaiter{deadline: c.now.Add(d), ch: ch})
+	c.changed.Broadcast()
+	return ch
+}
+
+// Sleep blocks until the clock got advanced by at least the given duration.
+func (c *Clock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// Advance moves the clock forward, waking up the calls of After and Sleep
+// whose duration elapsed.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+	c.changed.Broadcast()
+}
+
+// Waiters returns the number of pending calls of After and Sleep.
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until there are at least n pending calls of After and
+// Sleep, e.g. to advance the clock only once the service waits for it.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.changed.Wait()
+	}
+}
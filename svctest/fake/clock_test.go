@@ -0,0 +1,42 @@
+package fake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClock(start)
+
+	assert.Equal(t, start, <-c.After(0))
+
+	slept := make(chan struct{})
+	go func() {
+		c.Sleep(time.Minute)
+		close(slept)
+	}()
+	after := c.After(2 * time.Minute)
+	c.BlockUntil(2)
+
+	c.Advance(59 * time.Second)
+	select {
+	case <-slept:
+		require.FailNow(t, "woke up too early")
+	case <-after:
+		require.FailNow(t, "woke up too early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	<-slept
+	assert.Equal(t, 1, c.Waiters())
+
+	c.Advance(time.Hour)
+	assert.Equal(t, start.Add(time.Hour+time.Minute), <-after)
+	assert.Equal(t, start.Add(time.Hour+time.Minute), c.Now())
+	assert.Zero(t, c.Waiters())
+}
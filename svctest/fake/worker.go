@@ -0,0 +1,375 @@
+// Package fake provides configurable workers simulating well-behaved and
+// misbehaving svc workers in tests. It does not depend on svc, so svc's own
+// tests can use it as well.
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Method is a lifecycle method of a worker.
+type Method string
+
+// Lifecycle methods recorded by a Worker.
+const (
+	MethodInit      Method = "Init"
+	MethodRun       Method = "Run"
+	MethodTerminate Method = "Terminate"
+)
+
+// Call is a recorded call of a lifecycle method.
+type Call struct {
+	Worker string
+	Method Method
+	Start  time.Time
+	End    time.Time
+	// Err is the error returned by the call, or the value it panicked with.
+	Err error
+}
+
+// String returns the worker's name and the method, e.g. `db.Init`.
+func (c Call) String() string {
+	return c.Worker + "." + string(c.Method)
+}
+
+// Recorder records the lifecycle calls of one or several workers in the order
+// they were made.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Calls returns the recorded calls in the order they started. Calls still in
+// progress have a zero End.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call{}, r.calls...)
+}
+
+// Sequence returns the recorded calls formatted by Call.String, e.g.
+// `[db.Init api.Init db.Run ...]`.
+func (r *Recorder) Sequence() []string {
+	calls := r.Calls()
+	seq := make([]string, 0, len(calls))
+	for _, c := range calls {
+		seq = append(seq, c.String())
+	}
+	return seq
+}
+
+func (r *Recorder) start(worker string, method Method, now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Worker: worker, Method: method, Start: now})
+	return len(r.calls) - 1
+}
+
+func (r *Recorder) end(i int, err error, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[i].End = now
+	r.calls[i].Err = err
+}
+
+// TimeSource is the clock timing a Worker. It is implemented by Clock and
+// matches svc.Clock, so the service's clock can be shared with its workers.
+type TimeSource interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+var _ TimeSource = (*Clock)(nil)
+
+// realTime is the TimeSource of the time package.
+type realTime struct{}
+
+func (realTime) Now() time.Time                         { return time.Now() }
+func (realTime) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realTime) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+// Option defines the option type of NewWorker.
+type Option func(*Worker)
+
+// InitError makes every Init call fail with the given error.
+func InitError(err error) Option {
+	return InitFailures(-1, err)
+}
+
+// InitFailures makes the first n Init calls fail with the given error, e.g. to
+// test retries. A negative n fails every call.
+func InitFailures(n int, err error) Option {
+	return func(w *Worker) {
+		w.initFailures = n
+		w.initErr = err
+	}
+}
+
+// InitDelay makes Init block for the given duration before returning.
+func InitDelay(d time.Duration) Option {
+	return func(w *Worker) {
+		w.initDelay = d
+	}
+}
+
+// RunFor makes Run return after the given duration, unless the worker gets
+// terminated earlier. By default, Run returns once the worker gets terminated.
+func RunFor(d time.Duration) Option {
+	return func(w *Worker) {
+		w.runFor = d
+	}
+}
+
+// RunError makes Run return the given error, immediately or after the
+// duration set by RunFor.
+func RunError(err error) Option {
+	return func(w *Worker) {
+		w.runErr = err
+		w.returnFromRun()
+	}
+}
+
+// RunPanic makes Run panic with the given value, immediately or after the
+// duration set by RunFor.
+func RunPanic(v interface{}) Option {
+	return func(w *Worker) {
+		w.runPanic = v
+		w.returnFromRun()
+	}
+}
+
+// TerminateDelay makes Terminate hang for the given duration, e.g. to exceed
+// the termination grace period.
+func TerminateDelay(d time.Duration) Option {
+	return func(w *Worker) {
+		w.terminateDelay = d
+	}
+}
+
+// TerminateError makes Terminate return the given error.
+func TerminateError(err error) Option {
+	return func(w *Worker) {
+		w.terminateErr = err
+	}
+}
+
+// UnhealthyFor makes Healthy return an error until the given duration after Run
+// got called.
+func UnhealthyFor(d time.Duration) Option {
+	return func(w *Worker) {
+		w.unhealthyFor = d
+	}
+}
+
+// DeadAfter makes Alive return an error from the given duration after Run got
+// called.
+func DeadAfter(d time.Duration) Option {
+	return func(w *Worker) {
+		w.deadAfter = d
+	}
+}
+
+// WithClock makes the worker use the given clock, e.g. a fake Clock also passed
+// to svc.WithClock, for its delays, probes and recorded call times. Defaults to
+// the real time.
+func WithClock(clock TimeSource) Option {
+	return func(w *Worker) {
+		w.clock = clock
+	}
+}
+
+// Record records the lifecycle calls of the worker into the given recorder as
+// well, e.g. to verify the order of calls across workers.
+func Record(r *Recorder) Option {
+	return func(w *Worker) {
+		w.recorders = append(w.recorders, r)
+	}
+}
+
+// Worker is a fake svc worker implementing the Worker, Healther and Aliver
+// interfaces. By default it initializes successfully, runs until terminated,
+// and is healthy and alive.
+type Worker struct {
+	name  string
+	clock TimeSource
+
+	initFailures   int
+	initErr        error
+	initDelay      time.Duration
+	runFor         time.Duration
+	runErr         error
+	runPanic       interface{}
+	terminateDelay time.Duration
+	terminateErr   error
+	unhealthyFor   time.Duration
+	deadAfter      time.Duration
+	recorders      []*Recorder
+
+	mu        sync.Mutex
+	logger    *zap.Logger
+	inits     int
+	runStart  time.Time
+	healthErr error
+	aliveErr  error
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewWorker returns a fake worker with the given name, which is used to record
+// its calls.
+func NewWorker(name string, opts ...Option) *Worker {
+	w := &Worker{
+		name:   name,
+		clock:  realTime{},
+		runFor: -1,
+		stop:   make(chan struct{}),
+	}
+	w.recorders = []*Recorder{NewRecorder()}
+	for _, o := range opts {
+		o(w)
+	}
+	return w
+}
+
+// returnFromRun makes Run return immediately unless RunFor is set.
+func (w *Worker) returnFromRun() {
+	if w.runFor < 0 {
+		w.runFor = 0
+	}
+}
+
+// Name returns the worker's name.
+func (w *Worker) Name() string {
+	return w.name
+}
+
+// Calls returns the worker's recorded lifecycle calls.
+func (w *Worker) Calls() []Call {
+	return w.recorders[0].Calls()
+}
+
+// Logger returns the logger the worker got initialized with.
+func (w *Worker) Logger() *zap.Logger {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.logger
+}
+
+// SetHealthy sets the error returned by Healthy, overriding UnhealthyFor.
+func (w *Worker) SetHealthy(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.healthErr = err
+	w.unhealthyFor = 0
+}
+
+// SetAlive sets the error returned by Alive, overriding DeadAfter.
+func (w *Worker) SetAlive(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.aliveErr = err
+	w.deadAfter = 0
+}
+
+// Init implements the svc.Worker interface.
+func (w *Worker) Init(logger *zap.Logger) (err error) {
+	defer w.record(MethodInit)(&err)
+
+	w.clock.Sleep(w.initDelay)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logger = logger
+	w.inits++
+	if w.initFailures < 0 || w.inits <= w.initFailures {
+		return w.initErr
+	}
+	return nil
+}
+
+// Run implements the svc.Worker interface.
+func (w *Worker) Run() (err error) {
+	defer w.record(MethodRun)(&err)
+
+	w.mu.Lock()
+	w.runStart = w.clock.Now()
+	w.mu.Unlock()
+
+	if w.runFor >= 0 {
+		select {
+		case <-w.clock.After(w.runFor):
+		case <-w.stop:
+			return nil
+		}
+	} else {
+		<-w.stop
+		return nil
+	}
+
+	if w.runPanic != nil {
+		panic(w.runPanic)
+	}
+	return w.runErr
+}
+
+// Terminate implements the svc.Worker interface.
+func (w *Worker) Terminate() (err error) {
+	defer w.record(MethodTerminate)(&err)
+
+	w.stopOnce.Do(func() { close(w.stop) })
+	w.clock.Sleep(w.terminateDelay)
+	return w.terminateErr
+}
+
+// Healthy implements the svc.Healther interface.
+func (w *Worker) Healthy() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.unhealthyFor > 0 && (w.runStart.IsZero() || w.clock.Now().Sub(w.runStart) < w.unhealthyFor) {
+		return errors.New("not healthy yet")
+	}
+	return w.healthErr
+}
+
+// Alive implements the svc.Aliver interface.
+func (w *Worker) Alive() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.deadAfter > 0 && !w.runStart.IsZero() && w.clock.Now().Sub(w.runStart) >= w.deadAfter {
+		return errors.New("dead")
+	}
+	return w.aliveErr
+}
+
+// record records the start of a call and returns a function recording its
+// end, to be deferred with a pointer to the call's named error result.
+func (w *Worker) record(method Method) func(*error) {
+	indices := make([]int, len(w.recorders))
+	for i, r := range w.recorders {
+		indices[i] = r.start(w.name, method, w.clock.Now())
+	}
+	return func(err *error) {
+		callErr := *err
+		v := recover()
+		if v != nil {
+			callErr = fmt.Errorf("panic: %v", v)
+		}
+		for i, r := range w.recorders {
+			r.end(indices[i], callErr, w.clock.Now())
+		}
+		if v != nil {
+			panic(v)
+		}
+	}
+}
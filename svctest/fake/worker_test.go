@@ -0,0 +1,73 @@
+package fake
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWorker(t *testing.T) {
+	clock := NewClock(time.Now())
+	rec := NewRecorder()
+	w := NewWorker("dummy", WithClock(clock), Record(rec),
+		InitFailures(1, errors.New("not yet")),
+		RunFor(time.Hour),
+		TerminateError(errors.New("dirty")),
+	)
+
+	require.EqualError(t, w.Init(zap.NewNop()), "not yet")
+	require.NoError(t, w.Init(zap.NewNop()))
+
+	done := make(chan error)
+	go func() { done <- w.Run() }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	require.EqualError(t, w.Terminate(), "dirty")
+	require.NoError(t, <-done)
+
+	assert.Equal(t, []string{"dummy.Init", "dummy.Init", "dummy.Run", "dummy.Terminate"}, rec.Sequence())
+	calls := w.Calls()
+	require.Len(t, calls, 4)
+	for _, c := range calls {
+		assert.False(t, c.End.Before(c.Start))
+	}
+	assert.False(t, calls[2].End.Before(calls[3].Start), "Run returns once terminated")
+	assert.Equal(t, time.Minute, calls[3].Start.Sub(calls[2].Start), "calls are timed by the clock")
+}
+
+func TestWorkerRun(t *testing.T) {
+	assert.NoError(t, NewWorker("w", RunFor(time.Millisecond)).Run())
+	assert.EqualError(t, NewWorker("w", RunError(errors.New("boom"))).Run(), "boom")
+
+	w := NewWorker("w", RunPanic("boom"))
+	assert.PanicsWithValue(t, "boom", func() { _ = w.Run() })
+	assert.EqualError(t, w.Calls()[0].Err, "panic: boom")
+}
+
+func TestWorkerProbes(t *testing.T) {
+	clock := NewClock(time.Now())
+	w := NewWorker("w", WithClock(clock), RunFor(time.Hour), UnhealthyFor(20*time.Second), DeadAfter(40*time.Second))
+	assert.Error(t, w.Healthy(), "unhealthy before running")
+	assert.NoError(t, w.Alive())
+
+	go func() { _ = w.Run() }()
+	defer func() { _ = w.Terminate() }()
+	clock.BlockUntil(1)
+
+	clock.Advance(19 * time.Second)
+	assert.Error(t, w.Healthy())
+	clock.Advance(time.Second)
+	assert.NoError(t, w.Healthy())
+	assert.NoError(t, w.Alive())
+	clock.Advance(20 * time.Second)
+	assert.Error(t, w.Alive())
+
+	w.SetAlive(nil)
+	assert.NoError(t, w.Alive())
+	w.SetHealthy(errors.New("overloaded"))
+	assert.EqualError(t, w.Healthy(), "overloaded")
+}
@@ -0,0 +1,184 @@
+// Package svctest provides a harness to test services built with svc without
+// relying on sleeps or the framework's internals.
+package svctest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/voi-oss/svc"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const (
+	defaultTimeout      = 5 * time.Second
+	readyPollInterval   = 10 * time.Millisecond
+	workerTerminatedMsg = "Worker terminated"
+)
+
+// Service is a service under test. It logs into Logs, gathers the metrics
+// of Registry instead of the default Prometheus registry and serves the
+// internal HTTP server on an ephemeral port. Fatal log entries, e.g. when a
+// worker fails, stop the service instead of exiting the test binary.
+type Service struct {
+	*svc.SVC
+
+	// Logs holds every entry logged by the service.
+	Logs *observer.ObservedLogs
+	// Registry is the Prometheus registry gathered instead of the default one.
+	Registry *prometheus.Registry
+	// Timeout bounds WaitReady and Shutdown. Defaults to 5s.
+	Timeout time.Duration
+
+	t    testing.TB
+	done chan struct{}
+}
+
+// New builds a service named after the test. The given options are applied
+// after the harness' ones; they must neither replace the logger nor add
+// another HTTP server. A started service is shut down when the test ends.
+func New(t testing.TB, opts ...svc.Option) *Service {
+	t.Helper()
+
+	atom := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	core, logs := observer.New(atom)
+	registry := prometheus.NewRegistry()
+
+	s, err := svc.New(t.Name(), "test", append([]svc.Option{
+		svc.WithLogger(zap.New(core, zap.AddCaller(), zap.WithFatalHook(zapcore.WriteThenGoexit)), atom),
+		svc.WithPrometheusRegistry(registry),
+		svc.WithTerminationGracePeriod(defaultTimeout),
+		svc.WithHTTPServer("0"),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("could not create service: %v", err)
+	}
+
+	return &Service{
+		SVC:      s,
+		Logs:     logs,
+		Registry: registry,
+		Timeout:  defaultTimeout,
+		t:        t,
+	}
+}
+
+// StartAsync runs the service in a new goroutine.
+func (s *Service) StartAsync() {
+	s.t.Helper()
+	if s.done != nil {
+		s.t.Fatal("service already started")
+	}
+
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		s.Run()
+	}()
+	s.t.Cleanup(func() {
+		select {
+		case <-s.done:
+		default:
+			s.Shutdown()
+		}
+	})
+}
+
+// Done returns a channel that is closed once the started service stopped
+// running.
+func (s *Service) Done() <-chan struct{} {
+	return s.done
+}
+
+// WaitReady waits until all workers are running and healthy. It fails the test
+// if the service stops or is not ready within the timeout.
+func (s *Service) WaitReady() {
+	s.t.Helper()
+	if s.done == nil {
+		s.t.Fatal("service not started")
+	}
+
+	timeout := time.After(s.Timeout)
+	select {
+	case <-s.Started():
+	case <-s.done:
+		s.t.Fatal("service stopped before being ready")
+	case <-timeout:
+		s.t.Fatal("service not started within timeout")
+	}
+
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		err := s.Ready()
+		if err == nil {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-s.done:
+			s.t.Fatal("service stopped before being ready")
+		case <-timeout:
+			s.t.Fatalf("service not ready within timeout: %v", err)
+		}
+	}
+}
+
+// Shutdown shuts the started service down and waits for it to stop running.
+// It fails the test if the service does not stop within the timeout.
+func (s *Service) Shutdown() {
+	s.t.Helper()
+	if s.done == nil {
+		s.t.Fatal("service not started")
+	}
+
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	s.SVC.Shutdown()
+	select {
+	case <-s.done:
+	case <-time.After(s.Timeout):
+		s.t.Fatal("service not shut down within timeout")
+	}
+}
+
+// URL returns the URL of the given path on the internal HTTP server.
+func (s *Service) URL(path string) string {
+	return "http://" + s.HTTPAddr() + path
+}
+
+// AssertWorkerTerminated asserts that the worker with the given name got
+// terminated.
+func (s *Service) AssertWorkerTerminated(name string) bool {
+	s.t.Helper()
+	for _, e := range s.Logs.FilterMessage(workerTerminatedMsg).All() {
+		if e.ContextMap()["worker"] == name {
+			return true
+		}
+	}
+	s.t.Errorf("worker %s not terminated", name)
+	return false
+}
+
+// GatherMetric returns the gathered metric family with the given name, or nil
+// if there is none.
+func (s *Service) GatherMetric(name string) *dto.MetricFamily {
+	s.t.Helper()
+	families, err := s.Gatherer().Gather()
+	if err != nil {
+		s.t.Fatalf("could not gather metrics: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() == name {
+			return f
+		}
+	}
+	return nil
+}
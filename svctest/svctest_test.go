@@ -0,0 +1,78 @@
+package svctest
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/voi-oss/svc"
+	"github.com/voi-oss/svc/svctest/fake"
+	"go.uber.org/zap"
+)
+
+type worker struct {
+	healthy atomic.Bool
+	stop    chan struct{}
+}
+
+func (w *worker) Init(*zap.Logger) error { return nil }
+func (w *worker) Run() error             { <-w.stop; return nil }
+func (w *worker) Terminate() error       { close(w.stop); return nil }
+
+func (w *worker) Healthy() error {
+	if !w.healthy.Load() {
+		return errors.New("warming up")
+	}
+	return nil
+}
+
+func TestService(t *testing.T) {
+	s := New(t, svc.WithHealthz(), svc.WithMetrics())
+
+	w := &worker{stop: make(chan struct{})}
+	s.AddWorker("dummy-worker", w)
+	jobs := prometheus.NewCounter(prometheus.CounterOpts{Name: "jobs_total"})
+	s.Registry.MustRegister(jobs)
+	jobs.Add(2)
+
+	s.StartAsync()
+	<-s.Started()
+	require.Error(t, s.Ready())
+
+	w.healthy.Store(true)
+	s.WaitReady()
+
+	resp, err := http.Get(s.URL("/ready"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, 2.0, s.GatherMetric("jobs_total").GetMetric()[0].GetCounter().GetValue())
+	assert.Equal(t, 1.0, s.GatherMetric("svc_up").GetMetric()[0].GetGauge().GetValue())
+	assert.Nil(t, s.GatherMetric("go_goroutines"), "default registry is not gathered")
+
+	s.Shutdown()
+	s.AssertWorkerTerminated("dummy-worker")
+	s.AssertWorkerTerminated("internal-http-server")
+	assert.NotZero(t, s.Logs.FilterMessage("Service shutdown completed").Len())
+}
+
+func TestServiceWorkerFailure(t *testing.T) {
+	s := New(t)
+
+	w := fake.NewWorker("failing-worker", fake.RunFor(10*time.Millisecond), fake.RunPanic(errors.New("boom")))
+	s.AddWorker(w.Name(), w)
+
+	s.StartAsync()
+	s.WaitReady()
+	<-s.Done()
+
+	assert.Equal(t, 1, s.Logs.FilterMessage("Worker Init/Run failure").Len())
+	s.AssertWorkerTerminated("failing-worker")
+	assert.EqualError(t, w.Calls()[1].Err, "panic: boom")
+}
@@ -0,0 +1,212 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const defaultTickerMaxFailures = 3
+
+// TickerOption defines the option type of NewTickerWorker.
+type TickerOption func(*TickerWorker)
+
+// TickerImmediate runs the function as soon as the worker runs, instead of one
+// interval later.
+func TickerImmediate() TickerOption {
+	return func(w *TickerWorker) {
+		w.immediate = true
+	}
+}
+
+// TickerMaxFailures sets the number of consecutive failed runs after which the
+// worker reports itself unhealthy. Defaults to 3; 0 never reports it
+// unhealthy.
+func TickerMaxFailures(n int) TickerOption {
+	return func(w *TickerWorker) {
+		w.maxFailures = n
+	}
+}
+
+// TickerClock sets the clock timing the runs, e.g. a fake clock in tests.
+func TickerClock(clock Clock) TickerOption {
+	return func(w *TickerWorker) {
+		w.clock = clock
+	}
+}
+
+var (
+	_ Worker   = (*TickerWorker)(nil)
+	_ Healther = (*TickerWorker)(nil)
+	_ Gatherer = (*TickerWorker)(nil)
+)
+
+// TickerWorker is a worker calling a function periodically, waiting the
+// interval between the end of a run and the start of the next one. Terminate
+// cancels the context of the run in progress and waits for it to return; no
+// run starts once Terminate got called. A panicking run counts as a failed
+// one.
+type TickerWorker struct {
+	interval    time.Duration
+	fn          func(ctx context.Context) error
+	name        string
+	immediate   bool
+	maxFailures int
+	clock       Clock
+	logger      *zap.Logger
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+
+	mu        sync.Mutex
+	runDone   chan struct{}
+	failures  int
+	lastError error
+
+	registry *prometheus.Registry
+	runs     prometheus.Counter
+	failed   prometheus.Counter
+	duration prometheus.Histogram
+}
+
+// NewTickerWorker returns a worker calling fn every interval. The name labels
+// the worker's metrics, so it must be unique within the service, e.g. the
+// name the worker is added with.
+func NewTickerWorker(name string, interval time.Duration, fn func(ctx context.Context) error, opts ...TickerOption) (*TickerWorker, error) {
+	if name == "" {
+		return nil, fmt.Errorf("ticker name must not be empty")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("ticker interval must be positive, got %s", interval)
+	}
+
+	w := &TickerWorker{
+		interval:    interval,
+		fn:          fn,
+		name:        name,
+		maxFailures: defaultTickerMaxFailures,
+		clock:       realClock{},
+	}
+	for _, o := range opts {
+		o(w)
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+
+	labels := prometheus.Labels{"worker": w.name}
+	w.runs = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "ticker_worker_runs_total",
+		Help:        "Number of runs of the ticker worker.",
+		ConstLabels: labels,
+	})
+	w.failed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "ticker_worker_failures_total",
+		Help:        "Number of failed runs of the ticker worker.",
+		ConstLabels: labels,
+	})
+	w.duration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:        "ticker_worker_run_duration_seconds",
+		Help:        "Duration of the runs of the ticker worker.",
+		ConstLabels: labels,
+	})
+	w.registry = prometheus.NewRegistry()
+	w.registry.MustRegister(w.runs, w.failed, w.duration)
+
+	return w, nil
+}
+
+// Init implements the Worker interface.
+func (w *TickerWorker) Init(logger *zap.Logger) error {
+	w.logger = logger
+
+	return nil
+}
+
+// Run implements the Worker interface.
+func (w *TickerWorker) Run() error {
+	done := make(chan struct{})
+	defer close(done)
+	w.mu.Lock()
+	w.runDone = done
+	w.mu.Unlock()
+
+	if w.immediate {
+		w.tick()
+	}
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil
+		case <-w.clock.After(w.interval):
+			w.tick()
+		}
+	}
+}
+
+// Terminate implements the Worker interface.
+func (w *TickerWorker) Terminate() error {
+	w.stopOnce.Do(w.cancel)
+	w.mu.Lock()
+	done := w.runDone
+	w.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+
+	return nil
+}
+
+// Healthy implements the Healther interface.
+func (w *TickerWorker) Healthy() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxFailures > 0 && w.failures >= w.maxFailures {
+		return fmt.Errorf("%d consecutive failed runs: %w", w.failures, w.lastError)
+	}
+	return nil
+}
+
+// Gatherer implements the Gatherer interface.
+func (w *TickerWorker) Gatherer() prometheus.Gatherer {
+	return w.registry
+}
+
+// tick runs the function once, unless the worker got terminated meanwhile.
+func (w *TickerWorker) tick() {
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	start := w.clock.Now()
+	err := w.run()
+	w.duration.Observe(w.clock.Now().Sub(start).Seconds())
+	w.runs.Inc()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.failed.Inc()
+		w.failures++
+		w.lastError = err
+		w.logger.Error("Ticker run failed", zap.Error(err), zap.Int("consecutive_failures", w.failures))
+		return
+	}
+	w.failures = 0
+	w.lastError = nil
+}
+
+// run calls the function once, turning a panic into an error.
+func (w *TickerWorker) run() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			w.logger.Error("Ticker run panicked", zap.Any("panic", r), zap.Stack("stack"))
+		}
+	}()
+	return w.fn(w.ctx)
+}
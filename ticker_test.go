@@ -0,0 +1,167 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/voi-oss/svc/svctest/fake"
+)
+
+func tickerMetric(t *testing.T, w *TickerWorker, name string) float64 {
+	t.Helper()
+	families, err := w.Gatherer().Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		m := f.GetMetric()[0]
+		if m.GetHistogram() != nil {
+			return float64(m.GetHistogram().GetSampleCount())
+		}
+		return m.GetCounter().GetValue()
+	}
+	return 0
+}
+
+func TestTickerWorker(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	runs := make(chan struct{})
+	w, err := NewTickerWorker("poller", time.Minute, func(context.Context) error {
+		runs <- struct{}{}
+		return nil
+	}, TickerClock(clock))
+	require.NoError(t, err)
+	require.NoError(t, w.Init(zap.NewNop()))
+	go func() { _ = w.Run() }()
+
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		<-runs
+	}
+	require.NoError(t, w.Terminate())
+
+	assert.Equal(t, 3.0, tickerMetric(t, w, "ticker_worker_runs_total"))
+	assert.Equal(t, 3.0, tickerMetric(t, w, "ticker_worker_run_duration_seconds"))
+	assert.Equal(t, 0.0, tickerMetric(t, w, "ticker_worker_failures_total"))
+}
+
+func TestTickerWorkerImmediate(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	runs := make(chan struct{}, 1)
+	w, err := NewTickerWorker("poller", time.Minute, func(context.Context) error {
+		runs <- struct{}{}
+		return nil
+	}, TickerClock(clock), TickerImmediate())
+	require.NoError(t, err)
+	require.NoError(t, w.Init(zap.NewNop()))
+	go func() { _ = w.Run() }()
+
+	<-runs
+	require.NoError(t, w.Terminate())
+}
+
+func TestTickerWorkerHealthy(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	var fail int32 = 1
+	runs := make(chan struct{})
+	w, err := NewTickerWorker("poller", time.Second, func(context.Context) error {
+		defer func() { runs <- struct{}{} }()
+		if atomic.LoadInt32(&fail) == 1 {
+			return errors.New("failed")
+		}
+		return nil
+	}, TickerClock(clock), TickerMaxFailures(2))
+	require.NoError(t, err)
+	require.NoError(t, w.Init(zap.NewNop()))
+	go func() { _ = w.Run() }()
+
+	tick := func() {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		<-runs
+	}
+	tick()
+	assert.NoError(t, w.Healthy())
+	tick()
+	assert.EqualError(t, w.Healthy(), "2 consecutive failed runs: failed")
+	atomic.StoreInt32(&fail, 0)
+	tick()
+	assert.NoError(t, w.Healthy())
+	require.NoError(t, w.Terminate())
+
+	assert.Equal(t, 3.0, tickerMetric(t, w, "ticker_worker_runs_total"))
+	assert.Equal(t, 2.0, tickerMetric(t, w, "ticker_worker_failures_total"))
+}
+
+func TestTickerWorkerTerminate(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	started := make(chan struct{})
+	var runs int32
+	w, err := NewTickerWorker("poller", time.Second, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		close(started)
+		<-ctx.Done()
+		return nil
+	}, TickerClock(clock))
+	require.NoError(t, err)
+	require.NoError(t, w.Init(zap.NewNop()))
+	go func() { _ = w.Run() }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	<-started
+
+	require.NoError(t, w.Terminate())
+	clock.Advance(time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+	// Terminate before Run must neither block nor let Run start a run.
+	w, err = NewTickerWorker("poller", time.Second, func(context.Context) error {
+		t.Error("run after Terminate")
+		return nil
+	}, TickerImmediate())
+	require.NoError(t, err)
+	require.NoError(t, w.Init(zap.NewNop()))
+	require.NoError(t, w.Terminate())
+	require.NoError(t, w.Run())
+}
+
+func TestTickerWorkerPanic(t *testing.T) {
+	clock := fake.NewClock(time.Unix(0, 0))
+	runs := make(chan struct{})
+	w, err := NewTickerWorker("poller", time.Second, func(context.Context) error {
+		defer func() { runs <- struct{}{} }()
+		panic("boom")
+	}, TickerClock(clock), TickerMaxFailures(1))
+	require.NoError(t, err)
+	require.NoError(t, w.Init(zap.NewNop()))
+	go func() { _ = w.Run() }()
+
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		<-runs
+	}
+	clock.BlockUntil(1)
+	assert.EqualError(t, w.Healthy(), "2 consecutive failed runs: panic: boom")
+	require.NoError(t, w.Terminate())
+
+	assert.Equal(t, 2.0, tickerMetric(t, w, "ticker_worker_runs_total"))
+	assert.Equal(t, 2.0, tickerMetric(t, w, "ticker_worker_failures_total"))
+}
+
+func TestNewTickerWorkerInvalid(t *testing.T) {
+	fn := func(context.Context) error { return nil }
+	_, err := NewTickerWorker("poller", 0, fn)
+	assert.EqualError(t, err, "ticker interval must be positive, got 0s")
+	_, err = NewTickerWorker("", time.Second, fn)
+	assert.EqualError(t, err, "ticker name must not be empty")
+}
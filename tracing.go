@@ -0,0 +1,183 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	tracerName = "github.com/voi-oss/svc"
+
+	defaultTracingShutdownTimeout = 5 * time.Second
+)
+
+// TracingOption defines the option type of WithTracing.
+type TracingOption func(*tracing)
+
+// TracingExporter sets the span exporter, e.g. an stdouttrace exporter or a
+// tracetest.InMemoryExporter in tests. Defaults to an OTLP HTTP exporter
+// configured by the standard OTEL_EXPORTER_OTLP_* environment variables.
+func TracingExporter(exporter sdktrace.SpanExporter) TracingOption {
+	return func(t *tracing) {
+		t.exporter = exporter
+	}
+}
+
+// TracingSampler sets the sampler of the tracer provider. Defaults to sampling
+// every trace unless the parent span decides otherwise.
+func TracingSampler(sampler sdktrace.Sampler) TracingOption {
+	return func(t *tracing) {
+		t.sampler = sampler
+	}
+}
+
+// TracingResourceAttributes adds attributes to the traced resource, besides
+// the service's name and version.
+func TracingResourceAttributes(attrs ...attribute.KeyValue) TracingOption {
+	return func(t *tracing) {
+		t.attributes = append(t.attributes, attrs...)
+	}
+}
+
+// TracingShutdownTimeout sets how long pending spans get to be exported when
+// the service shuts down. Defaults to 5s.
+func TracingShutdownTimeout(d time.Duration) TracingOption {
+	return func(t *tracing) {
+		t.shutdownTimeout = d
+	}
+}
+
+type tracing struct {
+	exporter        sdktrace.SpanExporter
+	sampler         sdktrace.Sampler
+	attributes      []attribute.KeyValue
+	shutdownTimeout time.Duration
+
+	provider   *sdktrace.TracerProvider
+	propagator propagation.TextMapPropagator
+
+	// The global tracer provider and propagator registered before, restored
+	// on shutdown.
+	prevProvider   trace.TracerProvider
+	prevPropagator propagation.TextMapPropagator
+}
+
+// newTracing sets up the tracer provider of the named service and registers
+// it globally until shutdownTracing.
+func newTracing(name, version string, opts ...TracingOption) (*tracing, error) {
+	t := &tracing{
+		sampler:         sdktrace.ParentBased(sdktrace.AlwaysSample()),
+		shutdownTimeout: defaultTracingShutdownTimeout,
+	}
+	for _, o := range opts {
+		o(t)
+	}
+
+	ctx := context.Background()
+	if t.exporter == nil {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not create trace exporter: %w", err)
+		}
+		t.exporter = exporter
+	}
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(append([]attribute.KeyValue{
+			semconv.ServiceName(name),
+			semconv.ServiceVersion(version),
+		}, t.attributes...)...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create trace resource: %w", err)
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(t.exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(t.sampler),
+	)
+	t.propagator = propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	)
+
+	t.prevProvider = otel.GetTracerProvider()
+	t.prevPropagator = otel.GetTextMapPropagator()
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(t.propagator)
+
+	return t, nil
+}
+
+// TracerProvider returns the tracer provider set up by WithTracing, or the
+// global one if WithTracing is not used.
+func (s *SVC) TracerProvider() trace.TracerProvider {
+	if s.tracing == nil {
+		return otel.GetTracerProvider()
+	}
+	return s.tracing.provider
+}
+
+// traceHandler wraps the handler of the internal HTTP server with span
+// creation and context propagation if tracing is enabled.
+func (s *SVC) traceHandler(handler http.Handler) http.Handler {
+	if s.tracing == nil {
+		return handler
+	}
+	return otelhttp.NewHandler(handler, internalHTTPServerName,
+		otelhttp.WithTracerProvider(s.tracing.provider),
+		otelhttp.WithPropagators(s.tracing.propagator),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+}
+
+// initWorker initializes a worker within a span.
+func (s *SVC) initWorker(name string, init func() error) error {
+	_, span := s.tracer.Start(context.Background(), "init "+name,
+		trace.WithAttributes(attribute.String("svc.worker", name)))
+	defer span.End()
+
+	if err := init(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "worker initialization failed")
+		return err
+	}
+	return nil
+}
+
+// shutdownTracing exports the pending spans, stops the tracer provider and
+// restores the global tracer provider and propagator, unless they got replaced
+// in the meantime.
+func (s *SVC) shutdownTracing() {
+	if s.tracing == nil {
+		return
+	}
+	if otel.GetTracerProvider() == trace.TracerProvider(s.tracing.provider) {
+		otel.SetTracerProvider(s.tracing.prevProvider)
+		otel.SetTextMapPropagator(s.tracing.prevPropagator)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.tracing.shutdownTimeout)
+	defer cancel()
+	if err := s.tracing.provider.Shutdown(ctx); err != nil {
+		s.logger.Error("Could not flush traces", zap.Error(err))
+	}
+}
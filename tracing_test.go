@@ -0,0 +1,75 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+// flushedExporter keeps the exported spans on shutdown, unlike
+// tracetest.InMemoryExporter.
+type flushedExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (e flushedExporter) Shutdown(context.Context) error { return nil }
+
+func TestWithTracing(t *testing.T) {
+	prevProvider := noop.NewTracerProvider()
+	otel.SetTracerProvider(prevProvider)
+	prevPropagator := propagation.TraceContext{}
+	otel.SetTextMapPropagator(prevPropagator)
+
+	exporter := flushedExporter{tracetest.NewInMemoryExporter()}
+	s, err := New("dummy-service", "v1.2.3", WithHTTPServer("0"), WithTracing(TracingExporter(exporter)))
+	require.NoError(t, err)
+	assert.Equal(t, s.TracerProvider(), otel.GetTracerProvider(), "registered globally")
+
+	s.AddWorker("dummy-worker", &WorkerMock{
+		InitFunc:      func(*zap.Logger) error { return nil },
+		RunFunc:       func() error { return nil },
+		TerminateFunc: func() error { return nil },
+	})
+	s.AddWorker("failing-worker", &WorkerMock{
+		InitFunc:      func(*zap.Logger) error { return errors.New("boom") },
+		RunFunc:       func() error { return nil },
+		TerminateFunc: func() error { return nil },
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/live", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929b0e0e4736-00f067aa0ba902b7-01")
+	s.workers[internalHTTPServerName].(*httpServer).httpServer.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	s.Run()
+	assert.Equal(t, trace.TracerProvider(prevProvider), otel.GetTracerProvider(), "restored on shutdown")
+	assert.Equal(t, propagation.TextMapPropagator(prevPropagator), otel.GetTextMapPropagator(), "restored on shutdown")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+
+	httpSpan := spans[0]
+	assert.Equal(t, "GET /live", httpSpan.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929b0e0e4736", httpSpan.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", httpSpan.Parent.SpanID().String())
+	assert.Contains(t, httpSpan.Resource.Attributes(), semconv.ServiceName("dummy-service"))
+	assert.Contains(t, httpSpan.Resource.Attributes(), semconv.ServiceVersion("v1.2.3"))
+
+	assert.Equal(t, "init internal-http-server", spans[1].Name)
+	assert.Equal(t, "init dummy-worker", spans[2].Name)
+	assert.Equal(t, codes.Unset, spans[2].Status.Code)
+	assert.Equal(t, "init failing-worker", spans[3].Name)
+	assert.Equal(t, codes.Error, spans[3].Status.Code)
+}
@@ -27,3 +27,9 @@ type Healther interface {
 type Gatherer interface {
 	Gatherer() prometheus.Gatherer
 }
+
+// Reloader defines a worker that can apply configuration changes while
+// running. It gets passed pointers to the previous and the new configuration.
+type Reloader interface {
+	Reload(old, new interface{}) error
+}